@@ -5,15 +5,19 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"runtime"
 	"runtime/debug"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/en9inerd/go-pkgs/flagpair"
 	"github.com/en9inerd/j2z/internal/args"
+	"github.com/en9inerd/j2z/internal/config"
 	"github.com/en9inerd/j2z/internal/errs"
 	"github.com/en9inerd/j2z/internal/file"
 	applog "github.com/en9inerd/j2z/internal/log"
@@ -30,6 +34,22 @@ func splitFlag(flagValue string) []string {
 	return strings.Split(flagValue, ",")
 }
 
+// mustSplitMapFlag parses a comma-separated list of key=value pairs,
+// exiting on malformed input.
+func mustSplitMapFlag(name, flagValue string) map[string]string {
+	m := make(map[string]string)
+	for _, pair := range splitFlag(flagValue) {
+		key, value, ok := strings.Cut(pair, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" || value == "" {
+			slog.Error("invalid arguments", "flag", name, "err", fmt.Sprintf("expected key=value, got %q", pair))
+			os.Exit(1)
+		}
+		m[key] = value
+	}
+	return m
+}
+
 func versionString() string {
 	var revision, buildTime string
 	if info, ok := debug.ReadBuildInfo(); ok {
@@ -60,6 +80,22 @@ func main() {
 	zolaDir := r.String("zola-dir", "z", "", "Path to the Zola directory")
 	taxonomies := r.String("taxonomies", "", "tags,categories", "Optional comma-separated list of taxonomies")
 	extraKeys := r.String("extra-root-keys", "", "", "Optional comma-separated list of additional root front matter keys")
+	includeShortcodes := r.String("include-shortcodes", "", "", "Optional comma-separated list of include=shortcode mappings")
+	embedShortcodes := r.String("embed-shortcodes", "", "", "Optional comma-separated list of tag=shortcode mappings for gist, youtube, vimeo and twitter tags")
+	shortcodeStubs := r.Bool("shortcode-stubs", "", false, "Write stub templates for embed shortcodes missing from templates/shortcodes/")
+	octopress := r.Bool("octopress", "", false, "Convert Octopress codeblock, blockquote, img and pullquote tags")
+	comments := r.String("comments", "", args.CommentsDrop, "How to convert {% comment %} blocks: drop or html")
+	rulesFile := r.String("rules", "", "", "Optional TOML or YAML file with front matter mapping rules")
+	frontMatter := r.String("front-matter", "", args.FormatTOML, "Front matter output format: toml or yaml")
+	leftoverLiquid := r.String("leftover-liquid", "", args.LeftoverEscape, "How to write unconverted Liquid: escape or todo")
+	tocFlag := r.String("toc-flag", "", "toc", "The [extra] key set to true on pages with a Kramdown {:toc} marker (empty to disable)")
+	tocShortcode := r.String("toc-shortcode", "", "", "Optional shortcode name to insert in place of Kramdown {:toc} markers")
+	mathFlag := r.String("math-flag", "", "math", "The [extra] key set to true on pages containing $$ math (empty to disable)")
+	mathShortcode := r.String("math-shortcode", "", "", "Optional shortcode name to convert $$ math into instead of KaTeX delimiters")
+	langAliases := r.String("lang-aliases", "", "", "Optional comma-separated list of lang=zola-lang code block language mappings")
+	urlShortcode := r.String("url-shortcode", "", "", "Optional shortcode name to emit for relative_url/absolute_url expressions")
+	permalinks := r.String("permalinks", "", args.PermalinkPath, "How to map per-page permalinks: path or alias")
+	draftDate := r.String("draft-date", "", "", "Optional YYYY-MM-DD date for drafts without one (defaults to the filename date or file modification time)")
 	tzName := r.String("tz", "", "", "Optional timezone name")
 	aliases := r.Bool("aliases", "", false, "Enable aliases in the front matter")
 	dryRun := r.Bool("dry-run", "", false, "Preview conversion without writing files")
@@ -95,6 +131,21 @@ func main() {
 		Aliases:       *aliases,
 		DryRun:        *dryRun,
 		Tz:            timezone.GetTimeZone(*tzName),
+
+		IncludeShortcodes: mustSplitMapFlag("include-shortcodes", *includeShortcodes),
+		EmbedShortcodes:   mustSplitMapFlag("embed-shortcodes", *embedShortcodes),
+		ShortcodeStubs:    *shortcodeStubs,
+		Octopress:         *octopress,
+		Comments:          *comments,
+		FrontMatterFormat: *frontMatter,
+		LeftoverLiquid:    *leftoverLiquid,
+		TOCFlag:           *tocFlag,
+		TOCShortcode:      *tocShortcode,
+		MathFlag:          *mathFlag,
+		MathShortcode:     *mathShortcode,
+		LangAliases:       mustSplitMapFlag("lang-aliases", *langAliases),
+		URLShortcode:      *urlShortcode,
+		Permalinks:        *permalinks,
 	}
 
 	if cliArgs.JekyllDir == "" || cliArgs.ZolaDir == "" {
@@ -103,11 +154,64 @@ func main() {
 		os.Exit(1)
 	}
 
+	if cliArgs.Comments != args.CommentsDrop && cliArgs.Comments != args.CommentsHTML {
+		slog.Error("--comments must be drop or html", "value", cliArgs.Comments)
+		os.Exit(1)
+	}
+
+	if cliArgs.FrontMatterFormat != args.FormatTOML && cliArgs.FrontMatterFormat != args.FormatYAML {
+		slog.Error("--front-matter must be toml or yaml", "value", cliArgs.FrontMatterFormat)
+		os.Exit(1)
+	}
+
+	if cliArgs.LeftoverLiquid != args.LeftoverEscape && cliArgs.LeftoverLiquid != args.LeftoverTodo {
+		slog.Error("--leftover-liquid must be escape or todo", "value", cliArgs.LeftoverLiquid)
+		os.Exit(1)
+	}
+
+	if cliArgs.Permalinks != args.PermalinkPath && cliArgs.Permalinks != args.PermalinkAlias {
+		slog.Error("--permalinks must be path or alias", "value", cliArgs.Permalinks)
+		os.Exit(1)
+	}
+
+	if *draftDate != "" {
+		t, err := time.ParseInLocation(time.DateOnly, *draftDate, cliArgs.Tz)
+		if err != nil {
+			slog.Error("--draft-date must be a YYYY-MM-DD date", "value", *draftDate)
+			os.Exit(1)
+		}
+		cliArgs.DraftDate = t
+	}
+
+	if *rulesFile != "" {
+		rules, err := file.LoadRules(*rulesFile)
+		if err != nil {
+			slog.Error("failed to read rules file", "err", err)
+			os.Exit(1)
+		}
+		cliArgs.Rules = rules
+	}
+
+	site, err := config.Load(cliArgs.JekyllDir)
+	if err != nil {
+		slog.Error("failed to read Jekyll config", "err", err)
+		os.Exit(1)
+	}
+	cliArgs.Site = site
+
+	if cliArgs.ShortcodeStubs {
+		if err := file.WriteShortcodeStubs(&cliArgs); err != nil {
+			slog.Error("failed to write shortcode stubs", "err", err)
+			os.Exit(1)
+		}
+	}
+
 	var (
 		wg       sync.WaitGroup
 		total    atomic.Int64
 		errCount atomic.Int64
 		sem      = make(chan struct{}, runtime.NumCPU())
+		paths    []string
 	)
 
 	for path, err := range file.MarkdownFiles(cliArgs.JekyllDir) {
@@ -116,7 +220,24 @@ func main() {
 			errCount.Add(1)
 			continue
 		}
+		paths = append(paths, path)
+	}
+
+	// The index must be complete before any file is converted so that
+	// cross-page references can be resolved.
+	index, err := file.BuildSiteIndex(cliArgs.JekyllDir, paths)
+	if err != nil {
+		slog.Error("failed to build site index", "err", err)
+		os.Exit(1)
+	}
+	cliArgs.Index = index
+
+	collisions := file.PermalinkCollisions(paths)
+	for _, permalink := range slices.Sorted(maps.Keys(collisions)) {
+		slog.Warn("permalink used by several pages", "permalink", "/"+permalink+"/", "files", collisions[permalink])
+	}
 
+	for _, path := range paths {
 		total.Add(1)
 		wg.Add(1)
 		sem <- struct{}{} // acquire
@@ -152,6 +273,8 @@ func logProcessingError(path string, err error) {
 		slog.Error("filename error", "file", path, "name", fnErr.Name, "msg", fnErr.Msg)
 	} else if dtErr, ok := errors.AsType[*errs.DateError](err); ok {
 		slog.Error("date parse error", "file", dtErr.File, "value", dtErr.Value, "reason", dtErr.Reason)
+	} else if incErr, ok := errors.AsType[*errs.IncludeError](err); ok {
+		slog.Error("include error", "file", incErr.File, "target", incErr.Target, "msg", incErr.Msg, "err", incErr.Err)
 	} else {
 		slog.Error("failed to process file", "file", path, "err", err)
 	}
@@ -7,4 +7,4 @@ require (
 	gopkg.in/yaml.v3 v3.0.1
 )
 
-require github.com/en9inerd/go-pkgs v0.5.0 // indirect
+require github.com/en9inerd/go-pkgs v0.5.0
@@ -2,6 +2,30 @@ package args
 
 import "time"
 
+// Comment policies for {% comment %} blocks.
+const (
+	CommentsDrop = "drop"
+	CommentsHTML = "html"
+)
+
+// Front matter output formats.
+const (
+	FormatTOML = "toml"
+	FormatYAML = "yaml"
+)
+
+// Policies for Liquid left unconverted in the output.
+const (
+	LeftoverEscape = "escape"
+	LeftoverTodo   = "todo"
+)
+
+// Modes for mapping per-page Jekyll permalinks.
+const (
+	PermalinkPath  = "path"
+	PermalinkAlias = "alias"
+)
+
 type Args struct {
 	JekyllDir     string
 	ZolaDir       string
@@ -10,4 +34,101 @@ type Args struct {
 	Tz            *time.Location
 	Aliases       bool
 	DryRun        bool
+
+	// IncludeShortcodes maps Liquid include file names to Zola shortcode names.
+	IncludeShortcodes map[string]string
+
+	// EmbedShortcodes maps embed plugin tags (gist, youtube, vimeo,
+	// twitter) to Zola shortcode names, overriding the defaults.
+	EmbedShortcodes map[string]string
+	// ShortcodeStubs enables writing stub templates for the embed
+	// shortcodes that do not exist yet under templates/shortcodes/.
+	ShortcodeStubs bool
+
+	// Octopress enables conversion of the Octopress codeblock, blockquote,
+	// img and pullquote tags.
+	Octopress bool
+
+	// Comments selects how {% comment %} blocks are converted: CommentsDrop
+	// removes them, CommentsHTML turns them into HTML comments.
+	Comments string
+
+	// FrontMatterFormat selects the front matter output format: FormatTOML
+	// or FormatYAML.
+	FrontMatterFormat string
+
+	// LeftoverLiquid selects how Liquid that no converter handled is
+	// written: LeftoverEscape escapes it with Zola's {{/* */}} syntax,
+	// LeftoverTodo also wraps it in a visible TODO marker.
+	LeftoverLiquid string
+
+	// LangAliases maps code block language names to Zola language names,
+	// extending the built-in Rouge alias table.
+	LangAliases map[string]string
+
+	// TOCFlag names the [extra] key set to true on pages with a Kramdown
+	// table of contents marker; empty disables it.
+	TOCFlag string
+	// TOCShortcode, when set, names the shortcode inserted in place of
+	// the table of contents marker.
+	TOCShortcode string
+
+	// MathFlag names the [extra] key set to true on pages containing
+	// math; empty disables it.
+	MathFlag string
+	// MathShortcode, when set, names the shortcode math is converted
+	// into instead of KaTeX delimiters.
+	MathShortcode string
+
+	// Permalinks selects how per-page permalinks are mapped: PermalinkPath
+	// sets Zola's path to keep the URL, PermalinkAlias adds an alias that
+	// redirects to Zola's default URL.
+	Permalinks string
+
+	// DraftDate is the date given to drafts without one; when zero, the
+	// file modification time is used.
+	DraftDate time.Time
+
+	// Site holds the Jekyll site configuration read from _config.yml.
+	Site map[string]any
+	// URLShortcode, when set, names the shortcode that relative_url and
+	// absolute_url expressions are converted into.
+	URLShortcode string
+
+	// Index is the site-wide index used to resolve cross-page references.
+	Index *SiteIndex
+
+	// Rules are the front matter mapping rules read from the --rules file,
+	// applied in order.
+	Rules []Rule
+}
+
+// Rule is a declarative front matter mapping rule. A rule applies to the
+// pages matching all of its scope fields; a rule without scope applies to
+// every page. Defaults are set first, then keys are renamed, transformed
+// and dropped. Extra, Taxonomies and Root decide where the (renamed) keys
+// are placed, overriding the built-in placement.
+type Rule struct {
+	// Path is a glob matched against the page path relative to the Jekyll
+	// directory, e.g. "_posts/talks/*". "**" matches across directories.
+	Path string `toml:"path" yaml:"path"`
+	// Layout restricts the rule to pages with this layout value.
+	Layout string `toml:"layout" yaml:"layout"`
+
+	Defaults   map[string]any      `toml:"defaults" yaml:"defaults"`
+	Rename     map[string]string   `toml:"rename" yaml:"rename"`
+	Transform  map[string][]string `toml:"transform" yaml:"transform"`
+	Drop       []string            `toml:"drop" yaml:"drop"`
+	Extra      []string            `toml:"extra" yaml:"extra"`
+	Taxonomies []string            `toml:"taxonomies" yaml:"taxonomies"`
+	Root       []string            `toml:"root" yaml:"root"`
+}
+
+// SiteIndex maps Jekyll page references to Zola content paths (relative to
+// the content directory, e.g. "posts/amazing-node-red.md").
+type SiteIndex struct {
+	// Posts is keyed by {% post_url %} names, e.g. "2024-01-21-amazing-node-red".
+	Posts map[string]string
+	// Links is keyed by {% link %} source paths relative to the Jekyll directory.
+	Links map[string]string
 }
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFiles lists the Jekyll configuration file names in lookup order.
+var configFiles = []string{"_config.yml", "_config.yaml"}
+
+// Load reads the Jekyll site configuration from dir. A site without a
+// configuration file yields an empty map.
+func Load(dir string) (map[string]any, error) {
+	for _, name := range configFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		site := make(map[string]any)
+		if err := yaml.Unmarshal(data, &site); err != nil {
+			return nil, err
+		}
+		return site, nil
+	}
+	return map[string]any{}, nil
+}
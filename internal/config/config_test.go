@@ -0,0 +1,46 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	cfg := "url: https://example.com\nbaseurl: /blog\nauthor:\n  name: Jane\n"
+	if err := os.WriteFile(filepath.Join(dir, "_config.yml"), []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	site, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if site["url"] != "https://example.com" || site["baseurl"] != "/blog" {
+		t.Errorf("unexpected config: %v", site)
+	}
+	if author, ok := site["author"].(map[string]any); !ok || author["name"] != "Jane" {
+		t.Errorf("expected nested author map, got %v", site["author"])
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	site, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(site) != 0 {
+		t.Errorf("expected empty config, got %v", site)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "_config.yml"), []byte("url: [unclosed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
@@ -5,95 +5,85 @@ import (
 	"fmt"
 	"log/slog"
 
-	"github.com/en9inerd/j2z/internal/frontmatter"
+	"github.com/en9inerd/j2z/internal/args"
 )
 
-// CombineFrontMatterAndContent combines TOML front matter with markdown content.
-func CombineFrontMatterAndContent(tomlData []byte, content []byte) string {
-	processContent(&content)
-	content = frontmatter.Strip(content)
-	return fmt.Sprintf("+++\n%s+++%s", tomlData, content)
+// Context carries the state of a single page conversion through the
+// content passes and Liquid tag handlers.
+type Context struct {
+	Path     string
+	Args     *args.Args
+	Registry *Registry
+	// Page holds the page's parsed Jekyll front matter, used to resolve
+	// {{ page.* }} references.
+	Page map[string]any
+	// Extra collects the values content passes add to the page's [extra]
+	// front matter table.
+	Extra map[string]any
+
+	// includes is the stack of files currently being inlined by
+	// {% include_relative %}, innermost last.
+	includes []string
 }
 
-func processContent(content *[]byte) {
-	*content = normalizeMoreTag(*content)
-	*content = convertLiquidHighlight(*content)
-	*content = warnLiquidIncludes(*content)
+// NewContext returns a conversion context for the page at path using the
+// built-in tag handlers enabled by a.
+func NewContext(path string, a *args.Args) *Context {
+	return &Context{Path: path, Args: a, Registry: builtinRegistry(a)}
 }
 
-// convertLiquidHighlight converts Jekyll's {% highlight lang %} ... {% endhighlight %}
-// blocks into standard fenced code blocks (```lang ... ```).
-func convertLiquidHighlight(content []byte) []byte {
-	openTag := []byte("{%")
-	var result []byte
-
-	for len(content) > 0 {
-		idx := bytes.Index(content, openTag)
-		if idx == -1 {
-			result = append(result, content...)
-			break
-		}
-
-		closeIdx := bytes.Index(content[idx:], []byte("%}"))
-		if closeIdx == -1 {
-			result = append(result, content...)
-			break
-		}
-
-		inner := bytes.TrimSpace(content[idx+2 : idx+closeIdx])
-		if lang, ok := bytes.CutPrefix(inner, []byte("highlight")); ok {
-			lang = bytes.TrimSpace(lang)
-			endTag := []byte("{% endhighlight %}")
-			endIdx := bytes.Index(content[idx+closeIdx+2:], endTag)
-			if endIdx == -1 {
-				result = append(result, content[:idx+closeIdx+2]...)
-				content = content[idx+closeIdx+2:]
-				continue
-			}
-
-			codeBlock := content[idx+closeIdx+2 : idx+closeIdx+2+endIdx]
-			codeBlock = bytes.TrimPrefix(codeBlock, []byte("\n"))
-			codeBlock = bytes.TrimSuffix(codeBlock, []byte("\n"))
+// Convert runs all content passes and the registered Liquid tag handlers
+// over the prose of content, leaving code blocks and code spans untouched.
+func (c *Context) Convert(content []byte) ([]byte, error) {
+	content, err := c.convertLiquid(content)
+	if err != nil {
+		return nil, err
+	}
+	content = c.convertExtensions(content)
+	content = c.convertMarkdownAttr(content)
+	content = c.convertAbbreviations(content)
+	content = c.convertMath(content)
+	content = c.convertTables(content)
+	return c.convertIALs(c.convertTOC(content)), nil
+}
 
-			result = append(result, content[:idx]...)
-			result = append(result, []byte("```")...)
-			result = append(result, lang...)
-			result = append(result, '\n')
-			result = append(result, codeBlock...)
-			result = append(result, '\n')
-			result = append(result, []byte("```")...)
+// convertLiquid runs the passes that precede Markdown processing: the
+// Liquid tag handlers and the normalizations they depend on. Tag handlers
+// that produce markdown from a fragment, such as {% include_relative %},
+// use it so that the Kramdown passes run once, over the whole page.
+func (c *Context) convertLiquid(content []byte) ([]byte, error) {
+	code, blocks := c.Registry.scanSpans(content)
+	content = mapOutside(content, mergeSpans(code, blocks), normalizeMoreTag)
+	content = c.mapFenceLanguages(content)
+	return c.Registry.convert(c, content)
+}
 
-			content = content[idx+closeIdx+2+endIdx+len(endTag):]
-		} else {
-			result = append(result, content[:idx+closeIdx+2]...)
-			content = content[idx+closeIdx+2:]
-		}
+// SetExtra records a value for the page's [extra] front matter table.
+func (c *Context) SetExtra(key string, value any) {
+	if c.Extra == nil {
+		c.Extra = make(map[string]any)
 	}
+	c.Extra[key] = value
+}
 
-	return result
+// Warn logs a conversion warning attributed to the page being converted.
+func (c *Context) Warn(msg string, attrs ...any) {
+	slog.Warn(msg, append([]any{"file", c.Path}, attrs...)...)
 }
 
-// warnLiquidIncludes logs a warning for any {% include ... %} tags found
-// in the content, as these have no Zola equivalent.
-func warnLiquidIncludes(content []byte) []byte {
-	search := content
-	for {
-		idx := bytes.Index(search, []byte("{%"))
-		if idx == -1 {
-			break
-		}
-		closeIdx := bytes.Index(search[idx:], []byte("%}"))
-		if closeIdx == -1 {
-			break
-		}
-		inner := bytes.TrimSpace(search[idx+2 : idx+closeIdx])
-		if bytes.HasPrefix(inner, []byte("include")) {
-			slog.Warn("unsupported Liquid tag found (no Zola equivalent)",
-				"tag", string(bytes.TrimSpace(inner)))
-		}
-		search = search[idx+closeIdx+2:]
+// CombineFrontMatterAndContent combines the serialized front matter with
+// the converted markdown body, escaping any Liquid left in it. The front
+// matter is delimited by +++ for TOML and by --- for the YAML format.
+func CombineFrontMatterAndContent(frontMatter []byte, content []byte, ctx *Context) string {
+	content, n := ctx.escapeLeftover(content)
+	if n > 0 {
+		ctx.Warn("escaped unconverted Liquid", "count", n)
+	}
+	delim := "+++"
+	if ctx.Args.FrontMatterFormat == args.FormatYAML {
+		delim = "---"
 	}
-	return content
+	return fmt.Sprintf("%s\n%s%s%s", delim, frontMatter, delim, content)
 }
 
 // normalizeMoreTag replaces any variant of the <!--more--> tag
@@ -3,8 +3,14 @@ package content
 import (
 	"strings"
 	"testing"
+
+	"github.com/en9inerd/j2z/internal/args"
 )
 
+func newTestContext() *Context {
+	return NewContext("/fake/2024-01-01-test.md", &args.Args{})
+}
+
 func TestNormalizeMoreTag(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -108,7 +114,10 @@ func TestConvertLiquidHighlight(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := convertLiquidHighlight([]byte(tt.input))
+			got, err := newTestContext().Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 			if string(got) != tt.want {
 				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
 			}
@@ -118,9 +127,9 @@ func TestConvertLiquidHighlight(t *testing.T) {
 
 func TestCombineFrontMatterAndContent(t *testing.T) {
 	toml := []byte("title = \"Test\"\n")
-	content := []byte("---\ntitle: Test\n---\n\nBody text here.")
+	content := []byte("\nBody text here. {% if x %}")
 
-	result := CombineFrontMatterAndContent(toml, content)
+	result := CombineFrontMatterAndContent(toml, content, newTestContext())
 
 	if !strings.HasPrefix(result, "+++\n") {
 		t.Error("result should start with TOML delimiter +++")
@@ -134,4 +143,15 @@ func TestCombineFrontMatterAndContent(t *testing.T) {
 	if strings.Contains(result, "---") {
 		t.Error("result should not contain YAML delimiters")
 	}
+	if !strings.HasSuffix(result, "+++\nBody text here. {%/* if x */%}") {
+		t.Errorf("result should end with the escaped body, got %q", result)
+	}
+}
+
+func TestCombineFrontMatterAndContent_YAML(t *testing.T) {
+	ctx := NewContext("/fake/post.md", &args.Args{FrontMatterFormat: args.FormatYAML})
+	result := CombineFrontMatterAndContent([]byte("title: Test\n"), []byte("\nBody."), ctx)
+	if want := "---\ntitle: Test\n---\nBody."; result != want {
+		t.Errorf("\ngot:  %q\nwant: %q", result, want)
+	}
 }
@@ -0,0 +1,132 @@
+package content
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// embedShortcodes maps embed plugin tags to the Zola shortcodes they are
+// converted into unless overridden by Args.EmbedShortcodes.
+var embedShortcodes = map[string]string{
+	"gist":    "gist",
+	"youtube": "youtube",
+	"vimeo":   "vimeo",
+	"twitter": "tweet",
+	"tweet":   "tweet",
+}
+
+// tweetStub is the stub template shared by the twitter and tweet tags.
+const tweetStub = `<blockquote class="twitter-tweet"><a href="{{ url }}">{{ url }}</a></blockquote>
+<script async src="https://platform.twitter.com/widgets.js" charset="utf-8"></script>
+`
+
+// embedStubs holds a minimal template for each embed tag, written to
+// templates/shortcodes/ when the site has none.
+var embedStubs = map[string]string{
+	"gist": `<script src="{{ url }}.js{% if file %}?file={{ file }}{% endif %}"></script>
+`,
+	"youtube": `<div class="embed youtube">
+  <iframe src="https://www.youtube-nocookie.com/embed/{{ id }}" title="YouTube video" frameborder="0" allowfullscreen></iframe>
+</div>
+`,
+	"vimeo": `<div class="embed vimeo">
+  <iframe src="https://player.vimeo.com/video/{{ id }}" title="Vimeo video" frameborder="0" allowfullscreen></iframe>
+</div>
+`,
+	"twitter": tweetStub,
+	"tweet":   tweetStub,
+}
+
+// embedShortcode returns the shortcode name configured for an embed tag.
+func embedShortcode(a map[string]string, tagName string) string {
+	if name, ok := a[tagName]; ok {
+		return name
+	}
+	return embedShortcodes[tagName]
+}
+
+// ShortcodeStubs returns stub templates keyed by shortcode name for every
+// shortcode the embed tags are converted into.
+func ShortcodeStubs(overrides map[string]string) map[string]string {
+	stubs := make(map[string]string)
+	for tagName := range embedShortcodes {
+		stubs[embedShortcode(overrides, tagName)] = embedStubs[tagName]
+	}
+	return stubs
+}
+
+// gistTag converts {% gist user/id [file] %} from jekyll-gist.
+func gistTag(ctx *Context, tag *Tag) ([]byte, error) {
+	fields := splitFields(tag.Args)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, &Diagnostic{Msg: "expected gist id and optional file name"}
+	}
+
+	args := []string{"url=" + quoteShortcodeArg("https://gist.github.com/"+unquote(fields[0]))}
+	if len(fields) == 2 {
+		args = append(args, "file="+quoteShortcodeArg(unquote(fields[1])))
+	}
+	return shortcodeCall(embedShortcode(ctx.Args.EmbedShortcodes, tag.Name), args), nil
+}
+
+// videoTag converts {% youtube id %} and {% vimeo id %} embed tags, which
+// accept either a bare video id or a video URL.
+func videoTag(ctx *Context, tag *Tag) ([]byte, error) {
+	fields := splitFields(tag.Args)
+	if len(fields) == 0 {
+		return nil, &Diagnostic{Msg: "missing video id"}
+	}
+	if len(fields) > 1 {
+		ctx.Warn("extra embed arguments dropped", "tag", tag.String())
+	}
+
+	id, ok := videoID(tag.Name, unquote(fields[0]))
+	if !ok {
+		return nil, &Diagnostic{Msg: "cannot determine video id"}
+	}
+	return shortcodeCall(embedShortcode(ctx.Args.EmbedShortcodes, tag.Name), []string{"id=" + quoteShortcodeArg(id)}), nil
+}
+
+// tweetTag converts {% twitter url %} and {% tweet url %} embed tags.
+func tweetTag(ctx *Context, tag *Tag) ([]byte, error) {
+	fields := splitFields(tag.Args)
+	if len(fields) == 0 {
+		return nil, &Diagnostic{Msg: "missing tweet URL"}
+	}
+	// jekyll-twitter-plugin accepts an optional "oembed" API name first.
+	if fields[0] == "oembed" {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 || !isAbsoluteURL(unquote(fields[0])) {
+		return nil, &Diagnostic{Msg: "missing tweet URL"}
+	}
+	if len(fields) > 1 {
+		ctx.Warn("extra embed arguments dropped", "tag", tag.String())
+	}
+	return shortcodeCall(embedShortcode(ctx.Args.EmbedShortcodes, tag.Name), []string{"url=" + quoteShortcodeArg(unquote(fields[0]))}), nil
+}
+
+// videoID extracts the video id from a bare id or a YouTube/Vimeo URL.
+func videoID(provider, s string) (string, bool) {
+	if !isAbsoluteURL(s) {
+		return s, s != ""
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", false
+	}
+	if provider == "youtube" {
+		if v := u.Query().Get("v"); v != "" {
+			return v, true
+		}
+	}
+	id := path.Base(strings.TrimSuffix(u.Path, "/"))
+	return id, id != "" && id != "." && id != "/"
+}
+
+// shortcodeCall formats an inline Zola shortcode call.
+func shortcodeCall(name string, args []string) []byte {
+	return fmt.Appendf(nil, "{{ %s(%s) }}", name, strings.Join(args, ", "))
+}
@@ -0,0 +1,94 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/en9inerd/j2z/internal/args"
+)
+
+func TestEmbedTags(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		input     string
+		want      string
+	}{
+		{
+			name:  "gist with user",
+			input: "{% gist parkr/931c1c8d465a04042403 %}",
+			want:  `{{ gist(url="https://gist.github.com/parkr/931c1c8d465a04042403") }}`,
+		},
+		{
+			name:  "gist with file",
+			input: "{% gist 931c1c8d465a04042403 jekyll-private-gists.md %}",
+			want:  `{{ gist(url="https://gist.github.com/931c1c8d465a04042403", file="jekyll-private-gists.md") }}`,
+		},
+		{
+			name:  "youtube id",
+			input: "{% youtube dQw4w9WgXcQ %}",
+			want:  `{{ youtube(id="dQw4w9WgXcQ") }}`,
+		},
+		{
+			name:  "youtube watch url",
+			input: `{% youtube "https://www.youtube.com/watch?v=dQw4w9WgXcQ" %}`,
+			want:  `{{ youtube(id="dQw4w9WgXcQ") }}`,
+		},
+		{
+			name:  "youtube short url",
+			input: "{% youtube https://youtu.be/dQw4w9WgXcQ %}",
+			want:  `{{ youtube(id="dQw4w9WgXcQ") }}`,
+		},
+		{
+			name:  "vimeo url",
+			input: "{% vimeo https://vimeo.com/123456 %}",
+			want:  `{{ vimeo(id="123456") }}`,
+		},
+		{
+			name:  "twitter",
+			input: "{% twitter https://twitter.com/jekyllrb/status/1234 %}",
+			want:  `{{ tweet(url="https://twitter.com/jekyllrb/status/1234") }}`,
+		},
+		{
+			name:  "twitter with oembed and options",
+			input: "{% twitter oembed https://twitter.com/jekyllrb/status/1234 maxwidth=500 %}",
+			want:  `{{ tweet(url="https://twitter.com/jekyllrb/status/1234") }}`,
+		},
+		{
+			name:      "user named shortcode",
+			overrides: map[string]string{"youtube": "video"},
+			input:     "{% youtube abc %}",
+			want:      `{{ video(id="abc") }}`,
+		},
+		{
+			name:  "missing arguments left in place",
+			input: "{% gist %}",
+			want:  "{% gist %}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &args.Args{EmbedShortcodes: tt.overrides}
+			got, err := NewContext("/fake/post.md", a).Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShortcodeStubs(t *testing.T) {
+	stubs := ShortcodeStubs(map[string]string{"gist": "mygist"})
+
+	for _, name := range []string{"mygist", "youtube", "vimeo", "tweet"} {
+		if stubs[name] == "" {
+			t.Errorf("expected stub for %q", name)
+		}
+	}
+	if _, ok := stubs["gist"]; ok {
+		t.Error("overridden gist shortcode should not get a stub under its default name")
+	}
+}
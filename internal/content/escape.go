@@ -0,0 +1,116 @@
+package content
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/en9inerd/j2z/internal/args"
+)
+
+// escapeLiquid rewrites every Liquid tag and output expression in content
+// into Zola's ignored shortcode syntax ({{/* ... */}} and {%/* ... */%}),
+// which Zola renders back as the original {{ ... }} / {% ... %} text.
+func escapeLiquid(content []byte) []byte {
+	var result []byte
+	pos := 0
+
+	for {
+		tok, ok := nextTag(content, pos)
+		if !ok {
+			break
+		}
+		result = append(result, content[pos:tok.start]...)
+		result = append(result, escapeToken(content[tok.start:tok.end])...)
+		pos = tok.end
+	}
+
+	return append(result, content[pos:]...)
+}
+
+// escapeToken escapes a single {{ ... }} or {% ... %} token. Tokens that
+// are already escaped are returned unchanged.
+func escapeToken(tok []byte) []byte {
+	inner := tok[2 : len(tok)-2]
+	if len(inner) >= 4 && string(inner[:2]) == "/*" && string(inner[len(inner)-2:]) == "*/" {
+		return tok
+	}
+
+	var result []byte
+	result = append(result, tok[:2]...)
+	result = append(result, "/*"...)
+	result = append(result, inner...)
+	result = append(result, "*/"...)
+	result = append(result, tok[len(tok)-2:]...)
+	return result
+}
+
+// escapeLeftover escapes the Liquid still present in content after
+// conversion so that Zola's shortcode parser does not fail on it. Zola
+// shortcode calls produced by the converters and anything inside markdown
+// code are kept as they are. With the LeftoverTodo policy every escaped
+// token is also wrapped in a visible TODO marker. It returns the number of
+// escaped tokens.
+func (c *Context) escapeLeftover(content []byte) ([]byte, int) {
+	var result []byte
+	pos, count := 0, 0
+	code, _ := c.Registry.scanSpans(content)
+
+	for {
+		tok, ok := nextTag(content, pos)
+		if !ok {
+			break
+		}
+		if s, ok := spanAt(code, tok.start); ok {
+			result = append(result, content[pos:s.end]...)
+			pos = s.end
+			continue
+		}
+
+		raw := content[tok.start:tok.end]
+		escaped := escapeToken(raw)
+		result = append(result, content[pos:tok.start]...)
+		pos = tok.end
+		if isShortcodeToken(tok) || len(escaped) == len(raw) {
+			result = append(result, raw...)
+			continue
+		}
+
+		count++
+		if c.Args.LeftoverLiquid == args.LeftoverTodo {
+			result = append(result, "<mark>TODO: "...)
+			result = append(result, escaped...)
+			result = append(result, "</mark>"...)
+			continue
+		}
+		result = append(result, escaped...)
+	}
+
+	return append(result, content[pos:]...), count
+}
+
+// isShortcodeToken reports whether tok is a Zola shortcode call such as
+// {{ youtube(id="x") }} or {% quote() %}, or the {% end %} closing a
+// shortcode body.
+func isShortcodeToken(tok tagToken) bool {
+	if tok.trimLeft || tok.trimRight {
+		return false
+	}
+	call := tok.args
+	if !tok.output {
+		if tok.name == "end" && tok.args == "" {
+			return true
+		}
+		call = tok.name + tok.args
+	}
+
+	name, rest, ok := strings.Cut(call, "(")
+	if !ok || name == "" || !strings.HasSuffix(rest, ")") {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
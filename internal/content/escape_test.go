@@ -0,0 +1,107 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/en9inerd/j2z/internal/args"
+)
+
+func TestEscapeLiquid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "output expression",
+			input: "{{ page.title }}",
+			want:  "{{/* page.title */}}",
+		},
+		{
+			name:  "tags",
+			input: "{% if x %}y{% endif %}",
+			want:  "{%/* if x */%}y{%/* endif */%}",
+		},
+		{
+			name:  "already escaped",
+			input: "{{/* x() */}}",
+			want:  "{{/* x() */}}",
+		},
+		{
+			name:  "unclosed delimiter untouched",
+			input: "{{ x",
+			want:  "{{ x",
+		},
+		{
+			name:  "no liquid",
+			input: "plain",
+			want:  "plain",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeLiquid([]byte(tt.input)); string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeLeftover(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    string
+		input     string
+		want      string
+		wantCount int
+	}{
+		{
+			name:      "tags and outputs escaped",
+			input:     "{% if page.x %}{{ x | filter }}{% endif %}",
+			want:      "{%/* if page.x */%}{{/* x | filter */}}{%/* endif */%}",
+			wantCount: 3,
+		},
+		{
+			name:  "shortcode calls kept",
+			input: "{{ youtube(id=\"abc\") }}\n{% quote(author=\"a\") %}\nbody\n{% end %}",
+			want:  "{{ youtube(id=\"abc\") }}\n{% quote(author=\"a\") %}\nbody\n{% end %}",
+		},
+		{
+			name:  "already escaped kept",
+			input: "{{/* page.title */}}",
+			want:  "{{/* page.title */}}",
+		},
+		{
+			name:  "code untouched",
+			input: "```\n{% for x in y %}\n```\n\n`{{ x }}`",
+			want:  "```\n{% for x in y %}\n```\n\n`{{ x }}`",
+		},
+		{
+			name:      "whitespace control is not a shortcode",
+			input:     "{%- end -%}",
+			want:      "{%/*- end -*/%}",
+			wantCount: 1,
+		},
+		{
+			name:      "todo marker",
+			policy:    args.LeftoverTodo,
+			input:     "a {% for x in y %} b",
+			want:      "a <mark>TODO: {%/* for x in y */%}</mark> b",
+			wantCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := NewContext("/fake/post.md", &args.Args{LeftoverLiquid: tt.policy})
+			got, n := ctx.escapeLeftover([]byte(tt.input))
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+			if n != tt.wantCount {
+				t.Errorf("count = %d, want %d", n, tt.wantCount)
+			}
+		})
+	}
+}
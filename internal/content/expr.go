@@ -0,0 +1,97 @@
+package content
+
+import (
+	"fmt"
+	"strings"
+)
+
+// filter is a single Liquid filter application, e.g. `append: "/x"`.
+type filter struct {
+	name string
+	args []string
+}
+
+// expression is a parsed Liquid output expression: a value followed by
+// zero or more filters.
+type expression struct {
+	value   string
+	filters []filter
+}
+
+// parseExpression parses the text of a {{ ... }} output expression.
+func parseExpression(s string) (expression, error) {
+	parts := splitOutsideQuotes(s, '|')
+	e := expression{value: strings.TrimSpace(parts[0])}
+	if e.value == "" {
+		return expression{}, fmt.Errorf("empty expression")
+	}
+
+	for _, part := range parts[1:] {
+		name, rawArgs, _ := strings.Cut(part, ":")
+		f := filter{name: strings.TrimSpace(name)}
+		if f.name == "" {
+			return expression{}, fmt.Errorf("empty filter in %q", s)
+		}
+		if strings.TrimSpace(rawArgs) != "" {
+			for _, arg := range splitOutsideQuotes(rawArgs, ',') {
+				f.args = append(f.args, strings.TrimSpace(arg))
+			}
+		}
+		e.filters = append(e.filters, f)
+	}
+	return e, nil
+}
+
+// splitOutsideQuotes splits s on sep, ignoring separators inside single
+// or double quoted strings.
+func splitOutsideQuotes(s string, sep byte) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// stringLiteral returns the contents of a quoted Liquid string literal.
+func stringLiteral(s string) (string, bool) {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1], true
+	}
+	return "", false
+}
+
+// unquote strips one pair of matching single or double quotes from s.
+func unquote(s string) string {
+	if v, ok := stringLiteral(s); ok {
+		return v
+	}
+	return s
+}
+
+// lookup resolves a dotted variable path such as "author.name" in m.
+func lookup(m map[string]any, path string) (any, bool) {
+	var cur any = m
+	for key := range strings.SplitSeq(path, ".") {
+		node, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		cur, ok = node[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
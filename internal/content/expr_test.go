@@ -0,0 +1,74 @@
+package content
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    expression
+		wantErr bool
+	}{
+		{
+			name:  "variable",
+			input: "site.baseurl",
+			want:  expression{value: "site.baseurl"},
+		},
+		{
+			name:  "filters with arguments",
+			input: `"/a" | append: "b|c", 'd' | relative_url`,
+			want: expression{value: `"/a"`, filters: []filter{
+				{name: "append", args: []string{`"b|c"`, `'d'`}},
+				{name: "relative_url"},
+			}},
+		},
+		{
+			name:    "empty",
+			input:   "  ",
+			wantErr: true,
+		},
+		{
+			name:    "empty filter",
+			input:   "x | ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseExpression(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	m := map[string]any{"author": map[string]any{"name": "Jane"}, "title": "T"}
+
+	if v, ok := lookup(m, "author.name"); !ok || v != "Jane" {
+		t.Errorf("lookup(author.name) = %v, %v", v, ok)
+	}
+	if v, ok := lookup(m, "title"); !ok || v != "T" {
+		t.Errorf("lookup(title) = %v, %v", v, ok)
+	}
+	if _, ok := lookup(m, "title.x"); ok {
+		t.Error("lookup through a scalar should fail")
+	}
+	if _, ok := lookup(nil, "x"); ok {
+		t.Error("lookup in nil map should fail")
+	}
+}
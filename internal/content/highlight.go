@@ -0,0 +1,155 @@
+package content
+
+import (
+	"bytes"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// highlightTag converts Jekyll's {% highlight lang [options] %} ... {% endhighlight %}
+// blocks into standard fenced code blocks (```lang ... ```), translating
+// Rouge lexer names and options into their Zola equivalents. Raw regions
+// in the code are unwrapped and their Liquid escaped.
+func highlightTag(ctx *Context, tag *Tag) ([]byte, error) {
+	code, err := ctx.Registry.convertLiteral(ctx, tag.Body)
+	if err != nil {
+		return nil, err
+	}
+	code = bytes.TrimPrefix(code, []byte("\n"))
+	code = bytes.TrimSuffix(code, []byte("\n"))
+
+	fields := splitFields(tag.Args)
+	var info []string
+	if len(fields) > 0 && !strings.Contains(fields[0], "=") && !isHighlightOption(fields[0]) {
+		info = append(info, ctx.zolaLanguage(fields[0]))
+		fields = fields[1:]
+	}
+	for _, opt := range fields {
+		annotation, ok := highlightAnnotation(opt)
+		if !ok {
+			ctx.Warn("unsupported highlight option dropped", "tag", tag.String(), "option", opt)
+			continue
+		}
+		info = append(info, annotation)
+	}
+
+	var result []byte
+	result = append(result, "```"...)
+	result = append(result, strings.Join(info, ",")...)
+	result = append(result, '\n')
+	result = append(result, code...)
+	result = append(result, '\n')
+	result = append(result, "```"...)
+	return result, nil
+}
+
+// isHighlightOption reports whether s is a valueless Rouge option rather
+// than a language name.
+func isHighlightOption(s string) bool {
+	return s == "linenos"
+}
+
+// highlightAnnotation translates a single Rouge highlight option into the
+// equivalent Zola fence annotation.
+func highlightAnnotation(opt string) (string, bool) {
+	key, value, _ := strings.Cut(opt, "=")
+	value = unquote(value)
+
+	switch key {
+	case "linenos":
+		// Zola has a single line number style, so "table" and "inline" both map to it.
+		if value == "" || value == "table" || value == "inline" {
+			return "linenos", true
+		}
+	case "linenostart":
+		if _, err := strconv.Atoi(value); err == nil {
+			return "linenostart=" + value, true
+		}
+	case "hl_lines", "mark_lines":
+		if ranges, ok := lineRanges(value); ok {
+			return "hl_lines=" + ranges, true
+		}
+	}
+	return "", false
+}
+
+// lineRanges converts a space-separated list of line numbers into Zola's
+// hl_lines syntax, collapsing consecutive numbers into ranges ("2 3 5" -> "2-3 5").
+func lineRanges(s string) (string, bool) {
+	var lines []int
+	for f := range strings.FieldsSeq(strings.ReplaceAll(s, ",", " ")) {
+		if lo, hi, ok := strings.Cut(f, "-"); ok {
+			a, errA := strconv.Atoi(lo)
+			b, errB := strconv.Atoi(hi)
+			if errA != nil || errB != nil || a > b {
+				return "", false
+			}
+			for n := a; n <= b; n++ {
+				lines = append(lines, n)
+			}
+			continue
+		}
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return "", false
+		}
+		lines = append(lines, n)
+	}
+	if len(lines) == 0 {
+		return "", false
+	}
+
+	slices.Sort(lines)
+	lines = slices.Compact(lines)
+
+	var ranges []string
+	for i := 0; i < len(lines); {
+		j := i
+		for j+1 < len(lines) && lines[j+1] == lines[j]+1 {
+			j++
+		}
+		r := strconv.Itoa(lines[i])
+		if j > i {
+			r += "-" + strconv.Itoa(lines[j])
+		}
+		ranges = append(ranges, r)
+		i = j + 1
+	}
+	return strings.Join(ranges, " "), true
+}
+
+// splitFields splits s on whitespace that is not inside single or double
+// quotes.
+func splitFields(s string) []string {
+	var fields []string
+	var quote rune
+	start := -1
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			if start == -1 {
+				start = i
+			}
+		case unicode.IsSpace(c):
+			if start != -1 {
+				fields = append(fields, s[start:i])
+				start = -1
+			}
+		default:
+			if start == -1 {
+				start = i
+			}
+		}
+	}
+	if start != -1 {
+		fields = append(fields, s[start:])
+	}
+	return fields
+}
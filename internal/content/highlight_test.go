@@ -0,0 +1,89 @@
+package content
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHighlightOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "linenos",
+			input: "{% highlight ruby linenos %}\nx\n{% endhighlight %}",
+			want:  "```ruby,linenos\nx\n```",
+		},
+		{
+			name:  "linenos table",
+			input: "{% highlight ruby linenos=table %}\nx\n{% endhighlight %}",
+			want:  "```ruby,linenos\nx\n```",
+		},
+		{
+			name:  "hl_lines collapsed into ranges",
+			input: "{% highlight go hl_lines=\"2 3 5\" %}\nx\n{% endhighlight %}",
+			want:  "```go,hl_lines=2-3 5\nx\n```",
+		},
+		{
+			name:  "mark_lines and linenostart",
+			input: "{% highlight go linenos linenostart=10 mark_lines=\"4 1 2\" %}\nx\n{% endhighlight %}",
+			want:  "```go,linenos,linenostart=10,hl_lines=1-2 4\nx\n```",
+		},
+		{
+			name:  "options without language",
+			input: "{% highlight linenos %}\nx\n{% endhighlight %}",
+			want:  "```linenos\nx\n```",
+		},
+		{
+			name:  "unknown option dropped",
+			input: "{% highlight python wrap=true %}\nx\n{% endhighlight %}",
+			want:  "```python\nx\n```",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestContext().Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineRanges(t *testing.T) {
+	tests := []struct {
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{"2 3", "2-3", true},
+		{"1 3 4 5 9", "1 3-5 9", true},
+		{"5-7 1", "1 5-7", true},
+		{"1,2", "1-2", true},
+		{"a", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := lineRanges(tt.input)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("lineRanges(%q) = %q, %v, want %q, %v", tt.input, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSplitFields(t *testing.T) {
+	got := splitFields(`ruby  linenos hl_lines="2 3" x='a b'`)
+	want := []string{"ruby", "linenos", `hl_lines="2 3"`, `x='a b'`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,383 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// ial is a parsed Kramdown inline attribute list such as
+// {: #id .class key="value"}.
+type ial struct {
+	id      string
+	classes []string
+	attrs   [][2]string // key/value pairs in source order
+	refs    []string    // references to attribute list definitions
+}
+
+var (
+	// aldLine matches a Kramdown attribute list definition, {:name: ...}.
+	aldLine = regexp.MustCompile(`^\{:[\w-]+:`)
+	// trailingImage matches a markdown image ending right before a span IAL.
+	trailingImage = regexp.MustCompile(`!\[([^\]]*)\]\(\s*(\S+?)(?:\s+"([^"]*)")?\s*\)$`)
+	// atxClosing matches the optional closing sequence of an ATX heading.
+	atxClosing = regexp.MustCompile(`\s+#+\s*$`)
+	// headingAttrs matches a trailing {#id .class} heading attribute block.
+	headingAttrs = regexp.MustCompile(`\s+\{\s*([#.][^\s{}]+(?:\s+[#.][^\s{}]+)*)\s*\}$`)
+)
+
+// parseIAL parses the text between the {: and } delimiters.
+func parseIAL(s string) ial {
+	var a ial
+	for _, f := range splitFields(s) {
+		switch {
+		case strings.HasPrefix(f, "#") && len(f) > 1:
+			a.id = f[1:]
+		case strings.HasPrefix(f, ".") && len(f) > 1:
+			a.classes = append(a.classes, f[1:])
+		case strings.Contains(f, "="):
+			k, v, _ := strings.Cut(f, "=")
+			a.attrs = append(a.attrs, [2]string{k, unquote(v)})
+		default:
+			a.refs = append(a.refs, f)
+		}
+	}
+	return a
+}
+
+// merge adds the attributes of b to a, as Kramdown does for several IALs
+// applied to the same element.
+func (a *ial) merge(b ial) {
+	if b.id != "" {
+		a.id = b.id
+	}
+	a.classes = append(a.classes, b.classes...)
+	a.attrs = append(a.attrs, b.attrs...)
+	a.refs = append(a.refs, b.refs...)
+}
+
+// html formats the attributes as HTML attributes, each preceded by a space.
+func (a *ial) html() string {
+	var sb strings.Builder
+	if a.id != "" {
+		fmt.Fprintf(&sb, ` id="%s"`, html.EscapeString(a.id))
+	}
+	if len(a.classes) > 0 {
+		fmt.Fprintf(&sb, ` class="%s"`, html.EscapeString(strings.Join(a.classes, " ")))
+	}
+	for _, kv := range a.attrs {
+		fmt.Fprintf(&sb, ` %s="%s"`, kv[0], html.EscapeString(kv[1]))
+	}
+	return sb.String()
+}
+
+// heading formats the id and classes in Zola's {#id .class} heading
+// attribute syntax.
+func (a *ial) heading() string {
+	var parts []string
+	if a.id != "" {
+		parts = append(parts, "#"+a.id)
+	}
+	for _, c := range a.classes {
+		parts = append(parts, "."+c)
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+type blockKind int
+
+const (
+	blockBlank blockKind = iota
+	blockCode
+	blockIAL
+	blockHeading
+	blockText
+)
+
+// mdBlock is a run of lines of one kind; start and end include the
+// trailing newline.
+type mdBlock struct {
+	kind       blockKind
+	start, end int
+	attrs      *ial
+}
+
+// convertIALs translates Kramdown inline attribute lists outside code and
+// HTML comments: block IALs on headings become Zola heading attributes, on
+// other blocks a wrapping <div>, and span IALs on images an HTML <img>.
+// Anything else is dropped with a warning.
+func (c *Context) convertIALs(content []byte) []byte {
+	if !bytes.Contains(content, []byte("{:")) {
+		return content
+	}
+	code := c.opaqueSpans(content)
+	blocks := splitBlocks(content, code)
+
+	for i, b := range blocks {
+		if b.kind != blockIAL {
+			continue
+		}
+		line := bytes.TrimSpace(content[b.start:b.end])
+		if aldLine.Match(line) {
+			c.Warn("dropped Kramdown attribute list definition", "ial", string(line))
+			continue
+		}
+		a := parseIAL(string(line[2 : len(line)-1]))
+
+		// Consecutive IALs all apply to the same block.
+		prev, next := i-1, i+1
+		for prev >= 0 && blocks[prev].kind == blockIAL {
+			prev--
+		}
+		for next < len(blocks) && blocks[next].kind == blockIAL {
+			next++
+		}
+		var target *mdBlock
+		switch {
+		case prev >= 0 && blocks[prev].kind != blockBlank:
+			target = &blocks[prev]
+		case next < len(blocks) && blocks[next].kind != blockBlank:
+			target = &blocks[next]
+		default:
+			c.Warn("dropped Kramdown attribute list without a target", "ial", string(line))
+			continue
+		}
+		if target.attrs == nil {
+			target.attrs = &ial{}
+		}
+		target.attrs.merge(a)
+	}
+
+	var result []byte
+	for i, b := range blocks {
+		text := content[b.start:b.end]
+		switch b.kind {
+		case blockIAL:
+			continue
+		case blockHeading, blockText:
+			text = c.convertSpanIALs(text, b.start, code)
+		}
+		if b.attrs == nil {
+			result = append(result, text...)
+			continue
+		}
+
+		if len(b.attrs.refs) > 0 {
+			c.Warn("dropped unsupported Kramdown attribute list references", "refs", strings.Join(b.attrs.refs, " "))
+		}
+		switch b.kind {
+		case blockHeading:
+			result = append(result, c.headingWithAttrs(text, b.attrs, false)...)
+		case blockText:
+			if lines := bytes.SplitAfter(bytes.TrimRight(text, "\n"), []byte("\n")); len(lines) >= 2 && isSetextUnderline(lines[len(lines)-1]) {
+				result = append(result, c.headingWithAttrs(text, b.attrs, true)...)
+				continue
+			}
+			if len(result) > 0 && !bytes.HasSuffix(result, []byte("\n\n")) {
+				result = append(result, '\n')
+			}
+			result = fmt.Appendf(result, "<div%s>\n\n", b.attrs.html())
+			result = append(result, text...)
+			if !bytes.HasSuffix(text, []byte("\n")) {
+				result = append(result, '\n')
+			}
+			result = append(result, "\n</div>\n"...)
+			if next := nextBlock(blocks, i); next != nil && next.kind != blockBlank {
+				result = append(result, '\n')
+			}
+		default:
+			c.Warn("dropped Kramdown attribute list on code block", "attrs", strings.TrimSpace(b.attrs.html()))
+			result = append(result, text...)
+		}
+	}
+	return result
+}
+
+// nextBlock returns the first block after blocks[i] that is written to
+// the output, or nil.
+func nextBlock(blocks []mdBlock, i int) *mdBlock {
+	for j := i + 1; j < len(blocks); j++ {
+		if blocks[j].kind != blockIAL {
+			return &blocks[j]
+		}
+	}
+	return nil
+}
+
+// headingWithAttrs appends Zola heading attributes to the heading in text.
+// For setext headings they go at the end of the line before the underline.
+// An attribute block already ending the heading is merged into them, as
+// pulldown-cmark only reads the last one.
+func (c *Context) headingWithAttrs(text []byte, a *ial, setext bool) []byte {
+	if len(a.attrs) > 0 {
+		c.Warn("dropped key=value attributes on heading", "attrs", strings.TrimSpace((&ial{attrs: a.attrs}).html()))
+	}
+	if a.id == "" && len(a.classes) == 0 {
+		return text
+	}
+
+	body, trailer := text, []byte(nil)
+	if setext {
+		trimmed := bytes.TrimRight(text, "\n")
+		cut := bytes.LastIndexByte(trimmed, '\n')
+		body, trailer = text[:cut], text[cut:]
+	} else if bytes.HasSuffix(body, []byte("\n")) {
+		body, trailer = body[:len(body)-1], []byte("\n")
+	}
+	body = bytes.TrimRight(body, " \t\r")
+	if !setext {
+		body = atxClosing.ReplaceAll(body, nil)
+	}
+	if m := headingAttrs.FindSubmatchIndex(body); m != nil {
+		merged := parseIAL(string(body[m[2]:m[3]]))
+		merged.merge(*a)
+		body, a = body[:m[0]], &merged
+	}
+
+	var result []byte
+	result = append(result, body...)
+	result = append(result, ' ')
+	result = append(result, a.heading()...)
+	return append(result, trailer...)
+}
+
+// convertSpanIALs converts the span IALs in text, which starts at offset
+// base of the content scanned for code. IALs directly following an image
+// turn it into an HTML <img>; others are dropped with a warning.
+func (c *Context) convertSpanIALs(text []byte, base int, code []span) []byte {
+	result := make([]byte, 0, len(text))
+	pos := 0
+	for {
+		idx := bytes.Index(text[pos:], []byte("{:"))
+		if idx == -1 {
+			break
+		}
+		start := pos + idx
+		if s, ok := spanAt(code, base+start); ok {
+			end := min(s.end-base, len(text))
+			result = append(result, text[pos:end]...)
+			pos = end
+			continue
+		}
+		closeIdx := bytes.IndexAny(text[start:], "}\n")
+		if closeIdx == -1 || text[start+closeIdx] != '}' || isKramdownExtension(text[start:]) {
+			result = append(result, text[pos:start+2]...)
+			pos = start + 2
+			continue
+		}
+		end := start + closeIdx + 1
+		a := parseIAL(string(text[start+2 : end-1]))
+
+		before := text[pos:start]
+		if m := trailingImage.FindSubmatchIndex(before); m != nil {
+			result = append(result, before[:m[0]]...)
+			result = append(result, imageHTML(before, m, &a)...)
+		} else {
+			c.Warn("dropped Kramdown span attribute list", "ial", string(text[start:end]))
+			result = append(result, before...)
+		}
+		if len(a.refs) > 0 {
+			c.Warn("dropped unsupported Kramdown attribute list references", "refs", strings.Join(a.refs, " "))
+		}
+		pos = end
+	}
+	return append(result, text[pos:]...)
+}
+
+// imageHTML formats the markdown image matched by trailingImage in b as
+// an HTML <img> carrying the attributes of a.
+func imageHTML(b []byte, m []int, a *ial) []byte {
+	group := func(n int) string {
+		if m[2*n] == -1 {
+			return ""
+		}
+		return string(b[m[2*n]:m[2*n+1]])
+	}
+	src, alt, title := group(2), group(1), group(3)
+
+	result := fmt.Appendf(nil, `<img src="%s" alt="%s"`, html.EscapeString(src), html.EscapeString(alt))
+	if title != "" {
+		result = fmt.Appendf(result, ` title="%s"`, html.EscapeString(title))
+	}
+	result = append(result, a.html()...)
+	return append(result, '>')
+}
+
+// splitBlocks groups the lines of content into blank lines, code blocks,
+// block IAL lines, ATX headings and runs of other text.
+func splitBlocks(content []byte, code []span) []mdBlock {
+	var blocks []mdBlock
+	off := 0
+	for off < len(content) {
+		next := len(content)
+		if i := bytes.IndexByte(content[off:], '\n'); i != -1 {
+			next = off + i + 1
+		}
+		kind := lineKind(content, off, next, code)
+
+		if n := len(blocks); n > 0 && blocks[n-1].kind == kind && (kind == blockText || kind == blockCode) {
+			blocks[n-1].end = next
+		} else {
+			blocks = append(blocks, mdBlock{kind: kind, start: off, end: next})
+		}
+		off = next
+	}
+	return blocks
+}
+
+// lineKind classifies the line content[start:end]. Lines inside a span
+// that ends mid-line, such as an HTML comment, are text.
+func lineKind(content []byte, start, end int, code []span) blockKind {
+	if s, ok := spanAt(code, start); ok {
+		if s.end == len(content) || content[s.end-1] == '\n' {
+			return blockCode
+		}
+		if s.start < start {
+			return blockText
+		}
+	}
+	line := bytes.TrimRight(content[start:end], "\r\n")
+	indent, rest := lineIndent(line)
+	rest = bytes.TrimRight(rest, " \t")
+	switch {
+	case len(rest) == 0:
+		return blockBlank
+	case indent < 4 && isBlockIAL(rest):
+		return blockIAL
+	case indent < 4 && isATXHeading(rest):
+		return blockHeading
+	}
+	return blockText
+}
+
+// isBlockIAL reports whether line is a standalone Kramdown block IAL.
+func isBlockIAL(line []byte) bool {
+	return bytes.HasPrefix(line, []byte("{:")) && bytes.HasSuffix(line, []byte("}")) &&
+		!isKramdownExtension(line) && bytes.IndexByte(line[:len(line)-1], '}') == -1
+}
+
+// isKramdownExtension reports whether b starts a Kramdown extension tag
+// such as {::nomarkdown} or {:/nomarkdown}.
+func isKramdownExtension(b []byte) bool {
+	return bytes.HasPrefix(b, []byte("{::")) || bytes.HasPrefix(b, []byte("{:/"))
+}
+
+// isATXHeading reports whether rest starts an ATX heading.
+func isATXHeading(rest []byte) bool {
+	n := 0
+	for n < len(rest) && rest[n] == '#' {
+		n++
+	}
+	return n >= 1 && n <= 6 && (n == len(rest) || rest[n] == ' ' || rest[n] == '\t')
+}
+
+// isSetextUnderline reports whether line underlines a setext heading.
+func isSetextUnderline(line []byte) bool {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || (line[0] != '=' && line[0] != '-') {
+		return false
+	}
+	return len(bytes.Trim(line, string(line[:1]))) == 0
+}
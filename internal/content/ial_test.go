@@ -0,0 +1,125 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/en9inerd/j2z/internal/args"
+)
+
+func TestConvertIALs(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "heading after",
+			input: "## Setup\n{: #setup .wide}\n\nText.\n",
+			want:  "## Setup {#setup .wide}\n\nText.\n",
+		},
+		{
+			name:  "heading closing sequence",
+			input: "# Title ##\n{: .big}\n",
+			want:  "# Title {.big}\n",
+		},
+		{
+			name:  "existing heading attributes merged",
+			input: "## Heading {#custom .a}\n{: .b}\n",
+			want:  "## Heading {#custom .a .b}\n",
+		},
+		{
+			name:  "existing setext heading attributes merged",
+			input: "Title {#top}\n=====\n{: #main .c}\n",
+			want:  "Title {#main .c}\n=====\n",
+		},
+		{
+			name:  "setext heading",
+			input: "Title\n=====\n{: #top}\n",
+			want:  "Title {#top}\n=====\n",
+		},
+		{
+			name:  "paragraph after",
+			input: "Centered text.\n{: .center}\n\nNext.\n",
+			want:  "<div class=\"center\">\n\nCentered text.\n\n</div>\n\nNext.\n",
+		},
+		{
+			name:  "paragraph before",
+			input: "Intro.\n\n{: .note #n1}\nNoted.\n",
+			want:  "Intro.\n\n<div id=\"n1\" class=\"note\">\n\nNoted.\n\n</div>\n",
+		},
+		{
+			name:  "consecutive ials after",
+			input: "Para.\n{: .a}\n{: .b}\n",
+			want:  "<div class=\"a b\">\n\nPara.\n\n</div>\n",
+		},
+		{
+			name:  "consecutive ials before",
+			input: "{: .a}\n{: #b}\nPara.\n",
+			want:  "<div id=\"b\" class=\"a\">\n\nPara.\n\n</div>\n",
+		},
+		{
+			name:  "text right after ial is separated",
+			input: "a\n{: .x}\nb\n",
+			want:  "<div class=\"x\">\n\na\n\n</div>\n\nb\n",
+		},
+		{
+			name:  "image span ial",
+			input: "![Cat](/img/cat.png \"A cat\"){: width=\"50%\" .center}\n",
+			want:  "<img src=\"/img/cat.png\" alt=\"Cat\" title=\"A cat\" class=\"center\" width=\"50%\">\n",
+		},
+		{
+			name:  "span ial on emphasis dropped",
+			input: "This is *important*{: .red} text.\n",
+			want:  "This is *important* text.\n",
+		},
+		{
+			name:  "attribute list definition dropped",
+			input: "{:ref: .note}\n\nText.\n",
+			want:  "\nText.\n",
+		},
+		{
+			name:  "ial on code block dropped",
+			input: "```go\nx\n```\n{: .wide}\n",
+			want:  "```go\nx\n```\n",
+		},
+		{
+			name:  "inside code untouched",
+			input: "```\n{: .x}\n```\n\nUse `{: .x}` here.\n",
+			want:  "```\n{: .x}\n```\n\nUse `{: .x}` here.\n",
+		},
+		{
+			name:  "inside HTML comment untouched",
+			input: "<!--\nTODO\n{: .hidden}\n\n-->\n\nText\n{: .note}\n",
+			want:  "<!--\nTODO\n{: .hidden}\n\n-->\n\n<div class=\"note\">\n\nText\n\n</div>\n",
+		},
+		{
+			name:  "kramdown extension untouched",
+			input: "{::unknown}\n<b>x</b>\n{:/unknown}\n",
+			want:  "{::unknown}\n<b>x</b>\n{:/unknown}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestContext().Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertIALs_CommentTag(t *testing.T) {
+	ctx := NewContext("/fake/2024-01-01-test.md", &args.Args{Comments: args.CommentsHTML})
+	input := "{% comment %}\nTODO\n{: .hidden}\n{% endcomment %}\n"
+	got, err := ctx.Convert([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "<!--\nTODO\n{: .hidden}\n-->\n"; string(got) != want {
+		t.Errorf("\ngot:  %q\nwant: %q", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/en9inerd/j2z/internal/errs"
+)
+
+// maxIncludeDepth limits how deeply {% include_relative %} tags may nest.
+const maxIncludeDepth = 10
+
+// includeParam is a single key=value parameter of a Liquid include tag.
+type includeParam struct {
+	key    string
+	value  string
+	quoted bool
+}
+
+// includeTag converts {% include file.html key="value" %} into a call of
+// the Zola shortcode mapped to file.html in Args.IncludeShortcodes.
+func includeTag(ctx *Context, tag *Tag) ([]byte, error) {
+	name, rest, _ := strings.Cut(tag.Args, " ")
+	shortcode, ok := ctx.Args.IncludeShortcodes[name]
+	if !ok {
+		return nil, &Diagnostic{Msg: "unmapped Liquid include (no Zola shortcode configured)"}
+	}
+
+	params, err := parseIncludeParams(rest)
+	if err != nil {
+		return nil, &Diagnostic{Msg: err.Error()}
+	}
+
+	args := make([]string, 0, len(params))
+	for _, p := range params {
+		value := p.value
+		switch {
+		case p.quoted:
+			value = quoteShortcodeArg(value)
+		case isShortcodeLiteral(value):
+		default:
+			ctx.Warn("include parameter refers to a Liquid variable, passing it as a string",
+				"tag", tag.String(), "param", p.key)
+			value = quoteShortcodeArg(value)
+		}
+		args = append(args, p.key+"="+value)
+	}
+
+	return shortcodeCall(shortcode, args), nil
+}
+
+// parseIncludeParams parses Liquid include parameters of the forms
+// key=value, key="value" and key='value'.
+func parseIncludeParams(s string) ([]includeParam, error) {
+	var params []includeParam
+	s = strings.TrimSpace(s)
+
+	for s != "" {
+		key, rest, ok := strings.Cut(s, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t\"'") {
+			return nil, fmt.Errorf("malformed include parameter %q", s)
+		}
+		rest = strings.TrimLeft(rest, " \t")
+
+		var p includeParam
+		p.key = key
+		if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+			end := strings.IndexByte(rest[1:], rest[0])
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated quote in include parameter %q", key)
+			}
+			p.value, p.quoted = rest[1:end+1], true
+			rest = rest[end+2:]
+		} else {
+			end := strings.IndexAny(rest, " \t")
+			if end == -1 {
+				end = len(rest)
+			}
+			p.value = rest[:end]
+			rest = rest[end:]
+		}
+
+		params = append(params, p)
+		s = strings.TrimSpace(rest)
+	}
+
+	return params, nil
+}
+
+// isShortcodeLiteral reports whether an unquoted value can be passed to a
+// Zola shortcode as-is (a number or a boolean).
+func isShortcodeLiteral(v string) bool {
+	if v == "true" || v == "false" {
+		return true
+	}
+	_, err := strconv.ParseFloat(v, 64)
+	return err == nil
+}
+
+// quoteShortcodeArg quotes a string for use as a Zola shortcode argument,
+// picking a delimiter that does not occur in the value.
+func quoteShortcodeArg(v string) string {
+	for _, q := range []string{`"`, `'`, "`"} {
+		if !strings.Contains(v, q) {
+			return q + v + q
+		}
+	}
+	return strconv.Quote(v)
+}
+
+// includeRelativeTag inlines the file referenced by
+// {% include_relative path %}, resolved against the directory of the file
+// containing the tag. Liquid in the inlined content is converted
+// recursively; the Kramdown passes see it as part of the page.
+func includeRelativeTag(ctx *Context, tag *Tag) ([]byte, error) {
+	name, rest, _ := strings.Cut(tag.Args, " ")
+	if strings.TrimSpace(rest) != "" {
+		ctx.Warn("include_relative parameters are not supported, ignoring them", "tag", tag.String())
+	}
+
+	base := ctx.Path
+	if len(ctx.includes) > 0 {
+		base = ctx.includes[len(ctx.includes)-1]
+	}
+	target := filepath.Join(filepath.Dir(base), name)
+
+	if target == filepath.Clean(ctx.Path) || slices.Contains(ctx.includes, target) {
+		return nil, &errs.IncludeError{File: ctx.Path, Target: name, Msg: "include cycle detected"}
+	}
+	if len(ctx.includes) >= maxIncludeDepth {
+		return nil, &errs.IncludeError{File: ctx.Path, Target: name,
+			Msg: fmt.Sprintf("maximum include depth of %d exceeded", maxIncludeDepth)}
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil {
+		msg := "cannot read target"
+		if errors.Is(err, fs.ErrNotExist) {
+			msg = "target not found"
+		}
+		return nil, &errs.IncludeError{File: ctx.Path, Target: name, Msg: msg, Err: err}
+	}
+
+	ctx.includes = append(ctx.includes, target)
+	defer func() { ctx.includes = ctx.includes[:len(ctx.includes)-1] }()
+
+	return ctx.convertLiquid(data)
+}
@@ -0,0 +1,146 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/en9inerd/j2z/internal/args"
+	"github.com/en9inerd/j2z/internal/errs"
+)
+
+func TestIncludeTag(t *testing.T) {
+	a := &args.Args{
+		IncludeShortcodes: map[string]string{
+			"figure.html": "figure",
+			"note.html":   "note",
+		},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "mapped include without params",
+			input: "{% include note.html %}",
+			want:  "{{ note() }}",
+		},
+		{
+			name:  "double and single quoted params",
+			input: `{% include figure.html src="/img/a.png" alt='A "quoted" cat' %}`,
+			want:  `{{ figure(src="/img/a.png", alt='A "quoted" cat') }}`,
+		},
+		{
+			name:  "unquoted literal params",
+			input: "{% include figure.html width=300 center=true %}",
+			want:  "{{ figure(width=300, center=true) }}",
+		},
+		{
+			name:  "unquoted variable passed as string",
+			input: "{% include note.html text=page.title %}",
+			want:  `{{ note(text="page.title") }}`,
+		},
+		{
+			name:  "unmapped include left in place",
+			input: "{% include header.html %}",
+			want:  "{% include header.html %}",
+		},
+		{
+			name:  "malformed params left in place",
+			input: `{% include note.html text="oops %}`,
+			want:  `{% include note.html text="oops %}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewContext("/fake/post.md", a).Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncludeRelativeTag(t *testing.T) {
+	dir := t.TempDir()
+	post := filepath.Join(dir, "_posts", "2024-01-01-post.md")
+	files := map[string]string{
+		"_posts/snippet.md":       "snippet {% include_relative parts/nested.md %}",
+		"_posts/parts/nested.md":  "nested",
+		"_posts/cycle-a.md":       "a {% include_relative cycle-b.md %}",
+		"_posts/cycle-b.md":       "b {% include_relative cycle-a.md %}",
+		"_posts/self-include.md":  "{% include_relative 2024-01-01-post.md %}",
+		"_posts/parts/sibling.md": "sibling",
+		"_posts/abbr.md":          "*[HTML]: HyperText Markup Language\n",
+	}
+	for name, body := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("recursive include", func(t *testing.T) {
+		got, err := NewContext(post, &args.Args{}).Convert([]byte("before {% include_relative snippet.md %} after"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := "before snippet nested after"; string(got) != want {
+			t.Errorf("\ngot:  %q\nwant: %q", got, want)
+		}
+	})
+
+	t.Run("kramdown passes run over the whole page", func(t *testing.T) {
+		got, err := NewContext(post, &args.Args{}).Convert([]byte("HTML page.\n\n{% include_relative abbr.md %}"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := "<abbr title=\"HyperText Markup Language\">HTML</abbr> page.\n\n"; string(got) != want {
+			t.Errorf("\ngot:  %q\nwant: %q", got, want)
+		}
+	})
+
+	errTests := []struct {
+		name    string
+		input   string
+		wantMsg string
+	}{
+		{"missing target", "{% include_relative nope.md %}", "target not found"},
+		{"cycle between files", "{% include_relative cycle-a.md %}", "include cycle detected"},
+		{"cycle back to page", "{% include_relative self-include.md %}", "include cycle detected"},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewContext(post, &args.Args{}).Convert([]byte(tt.input))
+			incErr, ok := errors.AsType[*errs.IncludeError](err)
+			if !ok {
+				t.Fatalf("expected IncludeError, got %v", err)
+			}
+			if incErr.Msg != tt.wantMsg {
+				t.Errorf("got msg %q, want %q", incErr.Msg, tt.wantMsg)
+			}
+		})
+	}
+
+	t.Run("depth limit", func(t *testing.T) {
+		ctx := NewContext(post, &args.Args{})
+		for i := range maxIncludeDepth {
+			ctx.includes = append(ctx.includes, filepath.Join(dir, "_posts", "parts", fmt.Sprintf("level-%d.md", i)))
+		}
+		_, err := ctx.Convert([]byte("{% include_relative sibling.md %}"))
+		if _, ok := errors.AsType[*errs.IncludeError](err); !ok {
+			t.Fatalf("expected IncludeError, got %v", err)
+		}
+	})
+}
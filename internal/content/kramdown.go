@@ -0,0 +1,408 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// extensionOpen matches a Kramdown extension tag such as
+	// {::nomarkdown} or the self-closing {::options key="v" /}.
+	extensionOpen = regexp.MustCompile(`^\{::(\w+)([^}]*?)(/?)\}`)
+	// extensionClose matches {:/name} or the short form {:/}.
+	extensionClose = regexp.MustCompile(`\{:/(\w*)\}`)
+	// markdownAttr matches the markdown attribute of an HTML start tag.
+	markdownAttr = regexp.MustCompile(`\s+markdown=(?:"(\w*)"|'(\w*)'|(\w+))`)
+	// htmlStartTag matches an HTML start tag at the start of a line.
+	htmlStartTag = regexp.MustCompile(`^[ \t]{0,3}<([A-Za-z][\w-]*)(\s[^>]*)?>`)
+	// abbrDefinition matches a Kramdown abbreviation definition line.
+	abbrDefinition = regexp.MustCompile(`^ {0,3}\*\[([^\]]+)\]:[ \t]*(.*?)[ \t]*$`)
+	// linkReference matches a link reference definition line, but not a
+	// footnote definition.
+	linkReference = regexp.MustCompile(`^ {0,3}\[[^\]^][^\]]*\]:`)
+)
+
+// convertExtensions converts the Kramdown extension blocks outside code
+// and HTML comments: {::nomarkdown} bodies are written as raw HTML,
+// {::comment} blocks follow the comment policy, and {::options} tags are
+// dropped. Other extensions are reported and left in place.
+func (c *Context) convertExtensions(content []byte) []byte {
+	if !bytes.Contains(content, []byte("{::")) {
+		return content
+	}
+	code := c.opaqueSpans(content)
+
+	result := make([]byte, 0, len(content))
+	pos := 0
+	for i := 0; i < len(content); {
+		idx := bytes.Index(content[i:], []byte("{::"))
+		if idx == -1 {
+			break
+		}
+		i += idx
+		if s, ok := spanAt(code, i); ok {
+			i = s.end
+			continue
+		}
+		m := extensionOpen.FindSubmatchIndex(content[i:])
+		if m == nil {
+			i += 3
+			continue
+		}
+		name := string(content[i+m[2] : i+m[3]])
+		openEnd := i + m[1]
+
+		var body []byte
+		end := openEnd
+		if m[6] == m[7] { // not self-closing
+			cm := extensionClose.FindSubmatchIndex(content[openEnd:])
+			if cm == nil || (cm[3] > cm[2] && string(content[openEnd+cm[2]:openEnd+cm[3]]) != name) {
+				c.Warn("unterminated Kramdown extension", "extension", name)
+				i = openEnd
+				continue
+			}
+			body = content[openEnd : openEnd+cm[0]]
+			end = openEnd + cm[1]
+		}
+
+		var out []byte
+		switch name {
+		case "nomarkdown":
+			out = body
+		case "comment":
+			out, _ = commentTag(c, &Tag{Name: name, Body: body})
+		case "options":
+			c.Warn("dropped Kramdown options", "options", string(bytes.TrimSpace(content[i+m[4]:i+m[5]])))
+		default:
+			c.Warn("unsupported Kramdown extension", "extension", name)
+			i = end
+			continue
+		}
+
+		start := i
+		if lineStart, lineEnd, ok := ownLines(content, start, end); ok {
+			start, end = lineStart, lineEnd
+			out = rawHTMLBlock(out)
+		}
+		result = append(result, content[pos:start]...)
+		result = append(result, out...)
+		pos, i = end, end
+	}
+	return append(result, content[pos:]...)
+}
+
+// ownLines reports whether content[start:end] occupies whole lines, and
+// returns the offsets of the start of its first line and the end of its
+// last line, including the newline.
+func ownLines(content []byte, start, end int) (int, int, bool) {
+	lineStart := bytes.LastIndexByte(content[:start], '\n') + 1
+	if len(bytes.TrimSpace(content[lineStart:start])) != 0 {
+		return 0, 0, false
+	}
+	lineEnd := len(content)
+	if i := bytes.IndexByte(content[end:], '\n'); i != -1 {
+		lineEnd = end + i + 1
+	}
+	if len(bytes.TrimSpace(content[end:lineEnd])) != 0 {
+		return 0, 0, false
+	}
+	return lineStart, lineEnd, true
+}
+
+// rawHTMLBlock prepares raw HTML to be written as a markdown HTML block on
+// lines of its own. Blank lines, which would end the HTML block, are
+// removed.
+func rawHTMLBlock(b []byte) []byte {
+	var result []byte
+	for line := range bytes.SplitSeq(b, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		result = append(result, line...)
+		result = append(result, '\n')
+	}
+	return result
+}
+
+// convertMarkdownAttr rewrites HTML blocks carrying Kramdown's
+// markdown="1" (or "block") attribute so that Zola still renders their
+// inner markdown: the attribute is removed and the start and end tags
+// are put on lines of their own, separated from the dedented inner
+// markdown by blank lines.
+func (c *Context) convertMarkdownAttr(content []byte) []byte {
+	if !bytes.Contains(content, []byte("markdown=")) {
+		return content
+	}
+	code := c.opaqueSpans(content)
+
+	var result []byte
+	pos := 0
+	for off := 0; off < len(content); {
+		next := len(content)
+		if i := bytes.IndexByte(content[off:], '\n'); i != -1 {
+			next = off + i + 1
+		}
+		if _, ok := spanAt(code, off); ok || lineKind(content, off, next, code) != blockText {
+			off = next
+			continue
+		}
+		m := htmlStartTag.FindSubmatchIndex(content[off:next])
+		if m == nil || m[4] == -1 {
+			off = next
+			continue
+		}
+		attrs := content[off+m[4] : off+m[5]]
+		am := markdownAttr.FindSubmatchIndex(attrs)
+		if am == nil {
+			off = next
+			continue
+		}
+
+		name := string(content[off+m[2] : off+m[3]])
+		mode := ""
+		for g := 2; g < len(am); g += 2 {
+			if am[g] != -1 {
+				mode = string(attrs[am[g]:am[g+1]])
+			}
+		}
+		tag := slices.Concat(content[off:off+m[4]], attrs[:am[0]], attrs[am[1]:], []byte(">"))
+		tagEnd := off + m[1]
+
+		closeStart, closeEnd, ok := findCloseTag(content, tagEnd, name)
+		if !ok {
+			c.Warn("unterminated HTML block with markdown attribute", "tag", name)
+			off = next
+			continue
+		}
+
+		result = append(result, content[pos:off]...)
+		if mode != "1" && mode != "block" {
+			if mode != "0" {
+				c.Warn("unsupported markdown attribute value, treating the block as raw HTML", "tag", name, "value", mode)
+			}
+			result = append(result, tag...)
+			pos, off = tagEnd, tagEnd
+			continue
+		}
+
+		inner := c.convertMarkdownAttr(dedent(content[tagEnd:closeStart]))
+		result = append(result, tag...)
+		result = append(result, "\n\n"...)
+		result = append(result, inner...)
+		result = append(result, "\n\n"...)
+		result = append(result, content[closeStart:closeEnd]...)
+
+		pos, off = closeEnd, closeEnd
+		if rest := content[closeEnd:]; len(rest) > 0 && rest[0] == '\n' {
+			result = append(result, '\n')
+			pos, off = closeEnd+1, closeEnd+1
+			if !bytes.HasPrefix(content[off:], []byte("\n")) && off < len(content) {
+				result = append(result, '\n')
+			}
+		}
+	}
+	if result == nil {
+		return content
+	}
+	return append(result, content[pos:]...)
+}
+
+// findCloseTag finds the end tag matching an HTML element named name
+// whose start tag ends at from, taking nested elements of the same name
+// into account.
+func findCloseTag(content []byte, from int, name string) (start, end int, ok bool) {
+	openRe := regexp.MustCompile(`(?i)<(/?)` + regexp.QuoteMeta(name) + `(?:\s[^>]*)?>`)
+	depth := 0
+	for _, m := range openRe.FindAllSubmatchIndex(content[from:], -1) {
+		if m[3] == m[2] {
+			depth++
+			continue
+		}
+		if depth == 0 {
+			return from + m[0], from + m[1], true
+		}
+		depth--
+	}
+	return 0, 0, false
+}
+
+// dedent trims blank lines around b and removes the indentation common to
+// all its non-blank lines, so indented markdown is not read as code.
+func dedent(b []byte) []byte {
+	lines := bytes.Split(b, []byte("\n"))
+	for len(lines) > 0 && len(bytes.TrimSpace(lines[0])) == 0 {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && len(bytes.TrimSpace(lines[len(lines)-1])) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	common := -1
+	for _, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		n := len(line) - len(bytes.TrimLeft(line, " \t"))
+		if common == -1 || n < common {
+			common = n
+		}
+	}
+	for i, line := range lines {
+		lines[i] = line[min(max(common, 0), len(line)):]
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// abbreviation is a Kramdown abbreviation definition.
+type abbreviation struct {
+	abbr, title string
+}
+
+// convertAbbreviations removes Kramdown abbreviation definitions
+// (*[HTML]: HyperText Markup Language) and wraps the occurrences of the
+// abbreviations in the prose in <abbr> tags. Code and HTML comments are
+// left alone.
+func (c *Context) convertAbbreviations(content []byte) []byte {
+	if !bytes.Contains(content, []byte("*[")) {
+		return content
+	}
+	code := c.opaqueSpans(content)
+
+	var (
+		abbrs    []abbreviation
+		stripped []byte
+	)
+	for off := 0; off < len(content); {
+		next := len(content)
+		if i := bytes.IndexByte(content[off:], '\n'); i != -1 {
+			next = off + i + 1
+		}
+		line := bytes.TrimRight(content[off:next], "\r\n")
+		_, opaque := spanAt(code, off)
+		if m := abbrDefinition.FindSubmatch(line); m != nil && !opaque && lineKind(content, off, next, code) == blockText {
+			abbrs = append(abbrs, abbreviation{abbr: string(m[1]), title: string(m[2])})
+		} else {
+			stripped = append(stripped, content[off:next]...)
+		}
+		off = next
+	}
+	if len(abbrs) == 0 {
+		return content
+	}
+	// Longer abbreviations win over their prefixes.
+	slices.SortStableFunc(abbrs, func(a, b abbreviation) int { return len(b.abbr) - len(a.abbr) })
+
+	code = c.opaqueSpans(stripped)
+	code = mergeSpans(code, linkReferences(stripped, code))
+	return mapOutside(stripped, code, func(b []byte) []byte {
+		return expandAbbreviations(b, abbrs)
+	})
+}
+
+// linkReferences returns the spans of the link reference definition lines
+// of content outside code, whose URLs and titles must stay plain text.
+func linkReferences(content []byte, code []span) []span {
+	var refs []span
+	for off := 0; off < len(content); {
+		next := len(content)
+		if i := bytes.IndexByte(content[off:], '\n'); i != -1 {
+			next = off + i + 1
+		}
+		_, opaque := spanAt(code, off)
+		if !opaque && lineKind(content, off, next, code) == blockText && linkReference.Match(content[off:next]) {
+			refs = append(refs, span{start: off, end: next})
+		}
+		off = next
+	}
+	return refs
+}
+
+// expandAbbreviations wraps whole-word occurrences of abbrs in b in <abbr>
+// tags, skipping HTML tags, image descriptions, link destinations, Kramdown
+// attribute lists, Liquid and shortcode delimiters and $$ math.
+func expandAbbreviations(b []byte, abbrs []abbreviation) []byte {
+	var result []byte
+	for i := 0; i < len(b); {
+		if skip := opaqueRun(b[i:]); skip > 0 {
+			result = append(result, b[i:i+skip]...)
+			i += skip
+			continue
+		}
+		if isWordBefore(b, i) {
+			result = append(result, b[i])
+			i++
+			continue
+		}
+
+		matched := false
+		for _, a := range abbrs {
+			if !bytes.HasPrefix(b[i:], []byte(a.abbr)) || isWordAt(b, i+len(a.abbr)) {
+				continue
+			}
+			if a.title == "" {
+				result = fmt.Appendf(result, "<abbr>%s</abbr>", html.EscapeString(a.abbr))
+			} else {
+				result = fmt.Appendf(result, `<abbr title="%s">%s</abbr>`, html.EscapeString(a.title), html.EscapeString(a.abbr))
+			}
+			i += len(a.abbr)
+			matched = true
+			break
+		}
+		if !matched {
+			result = append(result, b[i])
+			i++
+		}
+	}
+	return result
+}
+
+// opaqueRun returns the length of the construct at the start of b in
+// which abbreviations are not expanded, or 0.
+func opaqueRun(b []byte) int {
+	pairs := [][2]string{{"<", ">"}, {"![", "]"}, {"](", ")"}, {"{:", "}"}, {"{{", "}}"}, {"{%", "%}"}, {"$$", "$$"}}
+	for _, p := range pairs {
+		if !bytes.HasPrefix(b, []byte(p[0])) {
+			continue
+		}
+		if p[0] == "<" && (len(b) < 2 || !isTagStart(b[1])) {
+			continue
+		}
+		if end := bytes.Index(b[len(p[0]):], []byte(p[1])); end != -1 {
+			return len(p[0]) + end + len(p[1])
+		}
+	}
+	return 0
+}
+
+// isTagStart reports whether ch can follow < in an HTML tag or comment.
+func isTagStart(ch byte) bool {
+	return ch == '/' || ch == '!' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+// isWordBefore reports whether the character before offset i is a word
+// character.
+func isWordBefore(b []byte, i int) bool {
+	if i == 0 {
+		return false
+	}
+	r, _ := utf8.DecodeLastRune(b[:i])
+	return isWordRune(r)
+}
+
+// isWordAt reports whether the character at offset i is a word character.
+func isWordAt(b []byte, i int) bool {
+	if i >= len(b) {
+		return false
+	}
+	r, _ := utf8.DecodeRune(b[i:])
+	return isWordRune(r)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,192 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/en9inerd/j2z/internal/args"
+)
+
+func TestKramdownExtensions(t *testing.T) {
+	tests := []struct {
+		name     string
+		comments string
+		input    string
+		want     string
+	}{
+		{
+			name:  "nomarkdown block",
+			input: "Before.\n\n{::nomarkdown}\n<table>\n\n<tr><td>*x*</td></tr>\n</table>\n{:/nomarkdown}\n\nAfter.\n",
+			want:  "Before.\n\n<table>\n<tr><td>*x*</td></tr>\n</table>\n\nAfter.\n",
+		},
+		{
+			name:  "inline nomarkdown with short closer",
+			input: "Text {::nomarkdown}<kbd>Ctrl</kbd>{:/} here.\n",
+			want:  "Text <kbd>Ctrl</kbd> here.\n",
+		},
+		{
+			name:  "comment dropped",
+			input: "a\n{::comment}\nnote\n{:/comment}\nb\n",
+			want:  "a\nb\n",
+		},
+		{
+			name:     "comment as html",
+			comments: args.CommentsHTML,
+			input:    "a {::comment}note{:/comment} b\n",
+			want:     "a <!--note--> b\n",
+		},
+		{
+			name:  "options dropped",
+			input: "{::options parse_block_html=\"true\" /}\nText.\n",
+			want:  "Text.\n",
+		},
+		{
+			name:  "unknown extension kept",
+			input: "{::foo}x{:/foo}\n",
+			want:  "{::foo}x{:/foo}\n",
+		},
+		{
+			name:  "code untouched",
+			input: "`{::nomarkdown}x{:/}`\n",
+			want:  "`{::nomarkdown}x{:/}`\n",
+		},
+		{
+			name:  "HTML comment untouched",
+			input: "<!-- {::nomarkdown}x{:/} -->\n",
+			want:  "<!-- {::nomarkdown}x{:/} -->\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewContext("/fake/post.md", &args.Args{Comments: tt.comments}).Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownAttr(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "markdown div",
+			input: "<div class=\"note\" markdown=\"1\">\n    **Note:** read *this*.\n\n    - item\n</div>\nAfter.\n",
+			want:  "<div class=\"note\">\n\n**Note:** read *this*.\n\n- item\n\n</div>\n\nAfter.\n",
+		},
+		{
+			name:  "single line",
+			input: "<section markdown='block'>*x*</section>\n",
+			want:  "<section>\n\n*x*\n\n</section>\n",
+		},
+		{
+			name:  "nested divs",
+			input: "<div markdown=\"1\">\n<div class=\"inner\" markdown=\"1\">\n*a*\n</div>\n</div>\n",
+			want:  "<div>\n\n<div class=\"inner\">\n\n*a*\n\n</div>\n\n</div>\n",
+		},
+		{
+			name:  "markdown=0 attribute removed",
+			input: "<div markdown=\"0\">*raw*</div>\n",
+			want:  "<div>*raw*</div>\n",
+		},
+		{
+			name:  "plain html untouched",
+			input: "<div class=\"x\">\n*x*\n</div>\n",
+			want:  "<div class=\"x\">\n*x*\n</div>\n",
+		},
+		{
+			name:  "HTML comment untouched",
+			input: "<!--\n<div markdown=\"1\">*x*</div>\n-->\n",
+			want:  "<!--\n<div markdown=\"1\">*x*</div>\n-->\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestContext().Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAbbreviations(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "expanded",
+			input: "The HTML spec.\n\n*[HTML]: HyperText Markup Language\n",
+			want:  "The <abbr title=\"HyperText Markup Language\">HTML</abbr> spec.\n\n",
+		},
+		{
+			name:  "whole words only",
+			input: "HTML5 and XHTML, but HTML.\n*[HTML]: HyperText Markup Language\n",
+			want:  "HTML5 and XHTML, but <abbr title=\"HyperText Markup Language\">HTML</abbr>.\n",
+		},
+		{
+			name:  "HTML comments untouched",
+			input: "HTML <!-- HTML -->\n<!--\n*[CSS]: Cascading Style Sheets\n-->\n*[HTML]: HyperText Markup Language\n",
+			want:  "<abbr title=\"HyperText Markup Language\">HTML</abbr> <!-- HTML -->\n<!--\n*[CSS]: Cascading Style Sheets\n-->\n",
+		},
+		{
+			name:  "longest match",
+			input: "W3C and W3\n*[W3]: Web\n*[W3C]: World Wide Web Consortium\n",
+			want:  "<abbr title=\"World Wide Web Consortium\">W3C</abbr> and <abbr title=\"Web\">W3</abbr>\n",
+		},
+		{
+			name:  "links, tags and code skipped",
+			input: "[HTML](/html/HTML) <a title=\"HTML\">x</a> `HTML`\n*[HTML]: Markup\n",
+			want:  "[<abbr title=\"Markup\">HTML</abbr>](/html/HTML) <a title=\"HTML\">x</a> `HTML`\n",
+		},
+		{
+			name:  "link reference definitions skipped",
+			input: "See [g] and [^1].\n\n[g]: https://example.com/HTML-guide \"HTML\"\n[^1]: About HTML.\n*[HTML]: HyperText\n",
+			want:  "See [g] and [^1].\n\n[g]: https://example.com/HTML-guide \"HTML\"\n[^1]: About <abbr title=\"HyperText\">HTML</abbr>.\n",
+		},
+		{
+			name:  "image descriptions skipped",
+			input: "![HTML logo](x.png) HTML\n*[HTML]: HyperText\n",
+			want:  "![HTML logo](x.png) <abbr title=\"HyperText\">HTML</abbr>\n",
+		},
+		{
+			name:  "attribute lists skipped",
+			input: "HTML\n{: title=\"HTML\"}\n*[HTML]: HyperText\n",
+			want:  "<div title=\"HTML\">\n\n<abbr title=\"HyperText\">HTML</abbr>\n\n</div>\n",
+		},
+		{
+			name:  "empty title",
+			input: "CSS\n*[CSS]:\n",
+			want:  "<abbr>CSS</abbr>\n",
+		},
+		{
+			name:  "definition in code untouched",
+			input: "```\n*[HTML]: x\n```\n",
+			want:  "```\n*[HTML]: x\n```\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestContext().Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
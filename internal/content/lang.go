@@ -0,0 +1,139 @@
+package content
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+)
+
+// rougeAliases maps Rouge lexer names and aliases that Zola's syntect
+// highlighter does not recognize to an equivalent it does.
+var rougeAliases = map[string]string{
+	"shell_session": "bash",
+	"console":       "bash",
+	"terminal":      "bash",
+	"shell":         "bash",
+	"sh-session":    "bash",
+	"zsh":           "bash",
+	"plaintext":     "txt",
+	"plain":         "txt",
+	"text":          "txt",
+	"none":          "txt",
+	"irb":           "ruby",
+	"rb":            "ruby",
+	"liquid":        "html",
+	"html+erb":      "erb",
+	"eruby":         "erb",
+	"rhtml":         "erb",
+	"objective_c":   "objc",
+	"objective-c":   "objc",
+	"c++":           "cpp",
+	"csharp":        "cs",
+	"c#":            "cs",
+	"docker":        "dockerfile",
+	"viml":          "vim",
+	"make":          "makefile",
+	"posh":          "powershell",
+	"ps1":           "powershell",
+	"coffeescript":  "coffee",
+	"common_lisp":   "lisp",
+	"elisp":         "lisp",
+	"proto":         "protobuf",
+	"json-doc":      "json",
+	"jsonc":         "json",
+	"hcl":           "terraform",
+	"tf":            "terraform",
+	"patch":         "diff",
+	"ex":            "elixir",
+	"exs":           "elixir",
+	"hs":            "haskell",
+	"golang":        "go",
+	"node":          "js",
+	"ecmascript":    "js",
+	"py3":           "python",
+	"python3":       "python",
+	"sass":          "scss",
+	"postgresql":    "sql",
+	"plsql":         "sql",
+	"psql":          "sql",
+	"kt":            "kotlin",
+	"ocaml":         "ml",
+	"conf":          "ini",
+	"properties":    "ini",
+}
+
+// zolaLanguages lists language names and extensions recognized by the
+// syntaxes bundled with Zola.
+var zolaLanguages = []string{
+	"asm", "bash", "bat", "c", "clojure", "cmake", "coffee", "cpp", "cs",
+	"css", "d", "dart", "diff", "dockerfile", "elixir", "elm", "erb",
+	"erlang", "fish", "fortran", "fs", "fsharp", "gleam", "go", "graphql",
+	"groovy", "h", "haskell", "hcl", "html", "ini", "java", "javascript",
+	"jinja2", "js", "json", "jsx", "julia", "kotlin", "latex", "less",
+	"lisp", "lua", "makefile", "markdown", "matlab", "md", "ml", "nginx",
+	"nim", "nix", "objc", "perl", "php", "powershell", "protobuf", "ps1",
+	"py", "python", "r", "racket", "rs", "rst", "ruby", "rust", "scala",
+	"scheme", "scss", "sh", "sql", "swift", "terraform", "tex", "toml",
+	"ts", "tsx", "txt", "typescript", "vim", "vue", "xml", "yaml", "yml",
+	"zig",
+}
+
+// zolaLanguage maps a Rouge language name to the name Zola's highlighter
+// knows, consulting Args.LangAliases before the built-in table. Names with
+// no known mapping are reported and returned unchanged.
+func (c *Context) zolaLanguage(lang string) string {
+	if lang == "" {
+		return lang
+	}
+	key := strings.ToLower(lang)
+	if mapped, ok := c.Args.LangAliases[key]; ok {
+		return mapped
+	}
+	if mapped, ok := rougeAliases[key]; ok {
+		return mapped
+	}
+	if !slices.Contains(zolaLanguages, key) {
+		c.Warn("code block language has no known Zola mapping", "lang", lang)
+		return lang
+	}
+	return key
+}
+
+// mapFenceLanguages rewrites the language of existing fenced code blocks
+// to the names Zola's highlighter knows.
+func (c *Context) mapFenceLanguages(content []byte) []byte {
+	code, _ := c.Registry.scanSpans(content)
+
+	var result []byte
+	pos := 0
+	for _, s := range code {
+		lineEnd := bytes.IndexByte(content[s.start:s.end], '\n')
+		if lineEnd == -1 {
+			continue
+		}
+		lineEnd += s.start
+		indent, rest := lineIndent(content[s.start:lineEnd])
+		fence := openingFence(rest)
+		if indent >= 4 || fence == nil {
+			continue
+		}
+
+		infoStart := lineEnd - len(rest) + len(fence)
+		info := content[infoStart:lineEnd]
+		trimmed := bytes.TrimLeft(info, " \t")
+		langEnd := bytes.IndexAny(trimmed, " \t\r,{")
+		if langEnd == -1 {
+			langEnd = len(trimmed)
+		}
+		lang := string(trimmed[:langEnd])
+		if lang == "" {
+			continue
+		}
+
+		langStart := infoStart + len(info) - len(trimmed)
+		result = append(result, content[pos:langStart]...)
+		result = append(result, c.zolaLanguage(lang)...)
+		pos = langStart + langEnd
+	}
+	return append(result, content[pos:]...)
+}
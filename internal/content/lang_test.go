@@ -0,0 +1,90 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/en9inerd/j2z/internal/args"
+)
+
+func TestLanguageMapping(t *testing.T) {
+	a := &args.Args{LangAliases: map[string]string{"mylang": "rust", "console": "sh"}}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "highlight with rouge alias",
+			input: "{% highlight shell_session %}\n$ ls\n{% endhighlight %}",
+			want:  "```bash\n$ ls\n```",
+		},
+		{
+			name:  "highlight with options",
+			input: "{% highlight plaintext linenos %}\nx\n{% endhighlight %}",
+			want:  "```txt,linenos\nx\n```",
+		},
+		{
+			name:  "known language kept and lowercased",
+			input: "{% highlight Go %}\nx\n{% endhighlight %}",
+			want:  "```go\nx\n```",
+		},
+		{
+			name:  "unknown language kept",
+			input: "{% highlight brainfuck %}\nx\n{% endhighlight %}",
+			want:  "```brainfuck\nx\n```",
+		},
+		{
+			name:  "existing fence",
+			input: "```irb\n>> 1 + 1\n```\n",
+			want:  "```ruby\n>> 1 + 1\n```\n",
+		},
+		{
+			name:  "tilde fence with space and attributes",
+			input: "~~~ plaintext {.wide}\nx\n~~~\n",
+			want:  "~~~ txt {.wide}\nx\n~~~\n",
+		},
+		{
+			name:  "fence with zola annotations",
+			input: "```erb,linenos\nx\n```\n",
+			want:  "```erb,linenos\nx\n```\n",
+		},
+		{
+			name:  "user alias",
+			input: "```mylang\nx\n```\n",
+			want:  "```rust\nx\n```\n",
+		},
+		{
+			name:  "user alias overrides built-in",
+			input: "```console\nx\n```\n",
+			want:  "```sh\nx\n```\n",
+		},
+		{
+			name:  "fence without language",
+			input: "```\nx\n```\n",
+			want:  "```\nx\n```\n",
+		},
+		{
+			name:  "CRLF line endings",
+			input: "```shell_session\r\n$ ls\r\n```\r\n\r\n```\r\nx\r\n```\r\n",
+			want:  "```bash\r\n$ ls\r\n```\r\n\r\n```\r\nx\r\n```\r\n",
+		},
+		{
+			name:  "indented code is not a fence",
+			input: "    ```irb\n",
+			want:  "    ```irb\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewContext("/fake/post.md", a).Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package content
+
+import "strings"
+
+// postURLTag rewrites {% post_url 2024-01-21-slug %} into a Zola internal
+// link to the converted post.
+func postURLTag(ctx *Context, tag *Tag) ([]byte, error) {
+	name := strings.TrimPrefix(unquote(tag.Args), "/")
+	name = strings.TrimSuffix(name, ".md")
+
+	var posts map[string]string
+	if ctx.Args.Index != nil {
+		posts = ctx.Args.Index.Posts
+	}
+	target, ok := posts[name]
+	if !ok {
+		return nil, &Diagnostic{Msg: "dangling post_url reference"}
+	}
+	return []byte("@/" + target), nil
+}
+
+// linkTag rewrites {% link _posts/2024-01-21-slug.md %} into a Zola internal
+// link to the converted page.
+func linkTag(ctx *Context, tag *Tag) ([]byte, error) {
+	name := strings.TrimPrefix(unquote(tag.Args), "/")
+
+	var links map[string]string
+	if ctx.Args.Index != nil {
+		links = ctx.Args.Index.Links
+	}
+	target, ok := links[name]
+	if !ok {
+		return nil, &Diagnostic{Msg: "dangling link reference"}
+	}
+	return []byte("@/" + target), nil
+}
@@ -0,0 +1,95 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/en9inerd/j2z/internal/args"
+)
+
+func TestLinkTags(t *testing.T) {
+	a := &args.Args{
+		Site: map[string]any{"url": "https://example.com", "baseurl": "/blog"},
+		Index: &args.SiteIndex{
+			Posts: map[string]string{
+				"2024-01-21-amazing-node-red": "posts/amazing-node-red.md",
+			},
+			Links: map[string]string{
+				"_posts/2024-01-21-amazing-node-red.md": "posts/amazing-node-red.md",
+			},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "post_url inside markdown link",
+			input: "[read]({% post_url 2024-01-21-amazing-node-red %})",
+			want:  "[read](@/posts/amazing-node-red.md)",
+		},
+		{
+			name:  "post_url with anchor",
+			input: "({% post_url 2024-01-21-amazing-node-red %}#setup)",
+			want:  "(@/posts/amazing-node-red.md#setup)",
+		},
+		{
+			name:  "link with leading slash",
+			input: "[read]({% link /_posts/2024-01-21-amazing-node-red.md %})",
+			want:  "[read](@/posts/amazing-node-red.md)",
+		},
+		{
+			name:  "quoted link",
+			input: `{% link "_posts/2024-01-21-amazing-node-red.md" %}`,
+			want:  "@/posts/amazing-node-red.md",
+		},
+		{
+			name:  "baseurl before post_url dropped",
+			input: "[read]({{ site.baseurl }}{% post_url 2024-01-21-amazing-node-red %})",
+			want:  "[read](@/posts/amazing-node-red.md)",
+		},
+		{
+			name:  "site url and baseurl before link dropped",
+			input: "[read]({{ site.url }}{{ site.baseurl }}{% link _posts/2024-01-21-amazing-node-red.md %})",
+			want:  "[read](@/posts/amazing-node-red.md)",
+		},
+		{
+			name:  "relative_url root before post_url dropped",
+			input: `[read]({{ "/" | relative_url }}{% post_url 2024-01-21-amazing-node-red %})`,
+			want:  "[read](@/posts/amazing-node-red.md)",
+		},
+		{
+			name:  "baseurl kept when not followed by a link",
+			input: "[about]({{ site.baseurl }}/about) {{ site.baseurl }} {% post_url 2024-01-21-amazing-node-red %}",
+			want:  "[about](/blog/about) /blog @/posts/amazing-node-red.md",
+		},
+		{
+			name:  "baseurl kept before dangling post_url",
+			input: "{{ site.baseurl }}{% post_url 2020-01-01-missing %}",
+			want:  "/blog{% post_url 2020-01-01-missing %}",
+		},
+		{
+			name:  "dangling post_url left in place",
+			input: "{% post_url 2020-01-01-missing %}",
+			want:  "{% post_url 2020-01-01-missing %}",
+		},
+		{
+			name:  "dangling link left in place",
+			input: "{% link about.md %}",
+			want:  "{% link about.md %}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewContext("/fake/post.md", a).Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
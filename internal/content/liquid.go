@@ -0,0 +1,351 @@
+package content
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var (
+	tagOpen     = []byte("{%")
+	tagClose    = []byte("%}")
+	outputOpen  = []byte("{{")
+	outputClose = []byte("}}")
+)
+
+// Tag is a parsed Liquid tag. For block tags, Body holds the text between
+// the opening and closing tags and Source spans the whole block.
+type Tag struct {
+	Name   string
+	Args   string
+	Body   []byte
+	Source []byte
+}
+
+// String returns the tag name and arguments as written inside the delimiters.
+func (t *Tag) String() string {
+	if t.Args == "" {
+		return t.Name
+	}
+	return t.Name + " " + t.Args
+}
+
+// TagHandler converts a single Liquid tag into replacement bytes.
+// Returning a *Diagnostic leaves the original tag in place and reports it;
+// any other error aborts the conversion of the page.
+type TagHandler func(ctx *Context, tag *Tag) ([]byte, error)
+
+// Diagnostic describes a Liquid tag that could not be converted.
+type Diagnostic struct {
+	Msg string
+}
+
+func (d *Diagnostic) Error() string { return d.Msg }
+
+// OutputHandler converts a Liquid output expression such as
+// {{ site.baseurl }}; expr is the trimmed text between the delimiters.
+// It reports ok=false when it does not handle expr so that the next
+// handler is tried. Errors are treated as for TagHandler.
+type OutputHandler func(ctx *Context, expr string) (out []byte, ok bool, err error)
+
+type tagSpec struct {
+	handler TagHandler
+	end     string // closing tag name for block tags, empty otherwise
+	literal bool   // block body ends at the first closing tag, no nesting
+}
+
+// Registry maps Liquid tag names to the handlers that convert them.
+type Registry struct {
+	tags    map[string]tagSpec
+	outputs []OutputHandler
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{tags: make(map[string]tagSpec)}
+}
+
+// Register adds a handler for a standalone tag such as {% include x %}.
+func (r *Registry) Register(name string, h TagHandler) {
+	r.tags[name] = tagSpec{handler: h}
+}
+
+// RegisterBlock adds a handler for a block tag that is closed by the tag
+// named end, such as {% highlight %} ... {% endhighlight %}.
+func (r *Registry) RegisterBlock(name, end string, h TagHandler) {
+	r.tags[name] = tagSpec{handler: h, end: end}
+}
+
+// RegisterLiteralBlock adds a handler for a block tag whose body is taken
+// literally up to the first closing tag, like {% raw %} ... {% endraw %}.
+func (r *Registry) RegisterLiteralBlock(name, end string, h TagHandler) {
+	r.tags[name] = tagSpec{handler: h, end: end, literal: true}
+}
+
+// RegisterOutput adds a handler for Liquid output expressions. Handlers
+// are tried in registration order.
+func (r *Registry) RegisterOutput(h OutputHandler) {
+	r.outputs = append(r.outputs, h)
+}
+
+// tagToken is the position and parsed contents of a single {% ... %} tag
+// or {{ ... }} output expression.
+type tagToken struct {
+	start, end int
+	output     bool
+	name, args string // for outputs, args holds the whole expression
+
+	// trimLeft and trimRight record the whitespace control markers
+	// {%- / {{- and -%} / -}}.
+	trimLeft, trimRight bool
+}
+
+// nextTag finds the first Liquid tag or output expression in content at or
+// after offset from. Openers without a matching closer are skipped.
+func nextTag(content []byte, from int) (tagToken, bool) {
+	for from < len(content) {
+		tagIdx := bytes.Index(content[from:], tagOpen)
+		outIdx := bytes.Index(content[from:], outputOpen)
+		if tagIdx == -1 && outIdx == -1 {
+			return tagToken{}, false
+		}
+
+		output := tagIdx == -1 || (outIdx != -1 && outIdx < tagIdx)
+		idx, closeDelim := tagIdx, tagClose
+		if output {
+			idx, closeDelim = outIdx, outputClose
+		}
+
+		start := from + idx
+		closeIdx := bytes.Index(content[start+2:], closeDelim)
+		if closeIdx == -1 {
+			from = start + 2
+			continue
+		}
+		end := start + 2 + closeIdx + len(closeDelim)
+
+		tok := tagToken{start: start, end: end, output: output}
+		inner := string(content[start+2 : end-len(closeDelim)])
+		inner, tok.trimLeft = strings.CutPrefix(inner, "-")
+		inner, tok.trimRight = strings.CutSuffix(inner, "-")
+		inner = strings.TrimSpace(inner)
+
+		if output {
+			tok.args = inner
+			return tok, true
+		}
+		tok.name = inner
+		if i := strings.IndexFunc(inner, unicode.IsSpace); i != -1 {
+			tok.name, tok.args = inner[:i], strings.TrimSpace(inner[i:])
+		}
+		return tok, true
+	}
+	return tagToken{}, false
+}
+
+// findEndTag returns the tag closing the block opened by open. Unless the
+// block is literal, nested blocks of the same name are taken into account.
+func findEndTag(content []byte, open tagToken, end string, literal bool) (tagToken, bool) {
+	depth := 0
+	pos := open.end
+	for {
+		tok, ok := nextTag(content, pos)
+		if !ok {
+			return tagToken{}, false
+		}
+		if tok.output {
+			pos = tok.end
+			continue
+		}
+		switch tok.name {
+		case open.name:
+			if !literal {
+				depth++
+			}
+		case end:
+			if depth == 0 {
+				return tok, true
+			}
+			depth--
+		}
+		pos = tok.end
+	}
+}
+
+// convert scans content for Liquid tags and output expressions and
+// replaces every one with a registered handler by the handler's output.
+// Unknown tags and expressions are copied verbatim, as is markdown code
+// apart from the literal blocks in it.
+func (r *Registry) convert(ctx *Context, content []byte) ([]byte, error) {
+	return r.convertTags(ctx, content, false)
+}
+
+// convertLiteral replaces only the literal blocks in content, such as
+// {% raw %}, leaving every other tag alone. Jekyll renders Liquid before
+// markdown, so raw regions are unwrapped inside code as well.
+func (r *Registry) convertLiteral(ctx *Context, content []byte) ([]byte, error) {
+	return r.convertTags(ctx, content, true)
+}
+
+func (r *Registry) convertTags(ctx *Context, content []byte, literalOnly bool) ([]byte, error) {
+	var result []byte
+	pos := 0
+	var code []span
+	if !literalOnly {
+		code, _ = r.scanSpans(content)
+	}
+	// siteURL is the offset in result of the site URL outputs that end at
+	// siteURLEnd in content, dropped when a Zola internal link follows.
+	siteURL, siteURLEnd := 0, -1
+
+	for {
+		tok, ok := nextTag(content, pos)
+		if !ok {
+			break
+		}
+
+		if s, ok := spanAt(code, tok.start); ok {
+			out, err := r.convertLiteral(ctx, content[pos:s.end])
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, out...)
+			pos = s.end
+			continue
+		}
+
+		if literalOnly && (tok.output || !r.tags[tok.name].literal) {
+			result = append(result, content[pos:tok.end]...)
+			pos = tok.end
+			continue
+		}
+
+		if tok.output {
+			out, err := r.convertOutput(ctx, tok.args)
+			if err != nil {
+				return nil, err
+			}
+			if out == nil {
+				result = append(result, content[pos:tok.end]...)
+				pos = tok.end
+				continue
+			}
+			result, pos = appendTrimmed(result, content, pos, tok, tok, out, false)
+			start := len(result) - len(out)
+			if tok.start == siteURLEnd {
+				start = siteURL
+			}
+			if isSiteURLOutput(tok.args) {
+				siteURL, siteURLEnd = start, pos
+			}
+			continue
+		}
+
+		spec, ok := r.tags[tok.name]
+		if !ok {
+			result = append(result, content[pos:tok.end]...)
+			pos = tok.end
+			continue
+		}
+
+		tag := &Tag{Name: tok.name, Args: tok.args}
+		last := tok
+		if spec.end != "" {
+			endTok, ok := findEndTag(content, tok, spec.end, spec.literal)
+			if !ok {
+				// Code that only mentions a block tag is not worth a warning.
+				if !literalOnly {
+					ctx.Warn("unterminated Liquid block tag", "tag", tag.String())
+				}
+				result = append(result, content[pos:tok.end]...)
+				pos = tok.end
+				continue
+			}
+			tag.Body = content[tok.end:endTok.start]
+			if tok.trimRight {
+				tag.Body = bytes.TrimLeft(tag.Body, liquidSpace)
+			}
+			if endTok.trimLeft {
+				tag.Body = bytes.TrimRight(tag.Body, liquidSpace)
+			}
+			last = endTok
+		}
+		tag.Source = content[tok.start:last.end]
+
+		out, err := spec.handler(ctx, tag)
+		if err != nil {
+			d, ok := errors.AsType[*Diagnostic](err)
+			if !ok {
+				return nil, err
+			}
+			ctx.Warn(d.Msg, "tag", tag.String())
+			result = append(result, content[pos:last.end]...)
+			pos = last.end
+			continue
+		}
+
+		if tok.start == siteURLEnd && bytes.HasPrefix(out, []byte("@/")) {
+			result = result[:siteURL]
+		}
+		result, pos = appendTrimmed(result, content, pos, tok, last, out, spec.end != "")
+	}
+
+	return append(result, content[pos:]...), nil
+}
+
+// liquidSpace is the whitespace removed by Liquid's whitespace control.
+const liquidSpace = " \t\r\n"
+
+// appendTrimmed appends the text between pos and the converted token
+// followed by its replacement out, applying the whitespace control of the
+// opening token first and closing token last. It returns the offset just
+// past the consumed input. Around block tags, a trimmed line break is kept
+// as a single newline so that the markdown block structure survives.
+func appendTrimmed(result, content []byte, pos int, first, last tagToken, out []byte, block bool) ([]byte, int) {
+	before := content[pos:first.start]
+	if first.trimLeft {
+		trimmed := bytes.TrimRight(before, liquidSpace)
+		keepNewline := block && bytes.IndexByte(before[len(trimmed):], '\n') != -1
+		before = trimmed
+		if keepNewline {
+			before = append(before[:len(before):len(before)], '\n')
+		}
+	}
+	result = append(result, before...)
+	result = append(result, out...)
+
+	pos = last.end
+	if last.trimRight {
+		rest := content[pos:]
+		n := len(rest) - len(bytes.TrimLeft(rest, liquidSpace))
+		if block && bytes.IndexByte(rest[:n], '\n') != -1 && !bytes.HasSuffix(result, []byte("\n")) {
+			result = append(result, '\n')
+		}
+		pos += n
+	}
+	return result, pos
+}
+
+// convertOutput runs the output handlers over expr and returns the first
+// handled result, or nil if the expression should be left as is.
+func (r *Registry) convertOutput(ctx *Context, expr string) ([]byte, error) {
+	for _, h := range r.outputs {
+		out, ok, err := h(ctx, expr)
+		if err != nil {
+			d, isDiag := errors.AsType[*Diagnostic](err)
+			if !isDiag {
+				return nil, err
+			}
+			ctx.Warn(d.Msg, "expr", expr)
+			return nil, nil
+		}
+		if ok {
+			if out == nil {
+				out = []byte{}
+			}
+			return out, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,180 @@
+package content
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/en9inerd/j2z/internal/args"
+)
+
+func TestRegistryConvert(t *testing.T) {
+	r := NewRegistry()
+	r.Register("shout", func(_ *Context, tag *Tag) ([]byte, error) {
+		return []byte(strings.ToUpper(tag.Args)), nil
+	})
+	r.RegisterBlock("wrap", "endwrap", func(_ *Context, tag *Tag) ([]byte, error) {
+		return []byte("[" + tag.Args + ":" + string(tag.Body) + "]"), nil
+	})
+	r.Register("broken", func(_ *Context, _ *Tag) ([]byte, error) {
+		return nil, &Diagnostic{Msg: "cannot convert"}
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "standalone tag",
+			input: "a {% shout hello world %} b",
+			want:  "a HELLO WORLD b",
+		},
+		{
+			name:  "tag without spaces",
+			input: "{%shout x%}",
+			want:  "X",
+		},
+		{
+			name:  "block tag",
+			input: "{% wrap x %}body{% endwrap %}",
+			want:  "[x:body]",
+		},
+		{
+			name:  "nested block of same name",
+			input: "{% wrap outer %}a{% wrap inner %}b{% endwrap %}c{% endwrap %}",
+			want:  "[outer:a{% wrap inner %}b{% endwrap %}c]",
+		},
+		{
+			name:  "unterminated block left as is",
+			input: "{% wrap x %}body",
+			want:  "{% wrap x %}body",
+		},
+		{
+			name:  "unknown tag copied verbatim",
+			input: "{% if x %}y{% endif %}",
+			want:  "{% if x %}y{% endif %}",
+		},
+		{
+			name:  "diagnostic keeps original tag",
+			input: "a {% broken thing %} b",
+			want:  "a {% broken thing %} b",
+		},
+		{
+			name:  "unclosed delimiter",
+			input: "a {% shout b",
+			want:  "a {% shout b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext()
+			ctx.Registry = r
+			got, err := ctx.Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryConvert_HandlerError(t *testing.T) {
+	errBoom := errors.New("boom")
+	r := NewRegistry()
+	r.Register("fail", func(_ *Context, _ *Tag) ([]byte, error) {
+		return nil, errBoom
+	})
+
+	ctx := newTestContext()
+	ctx.Registry = r
+	if _, err := ctx.Convert([]byte("{% fail %}")); !errors.Is(err, errBoom) {
+		t.Errorf("expected handler error, got %v", err)
+	}
+}
+
+func TestRegistryConvert_Outputs(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterOutput(func(_ *Context, expr string) ([]byte, bool, error) {
+		if expr == "empty" {
+			return nil, true, nil
+		}
+		return nil, false, nil
+	})
+	r.RegisterOutput(func(_ *Context, expr string) ([]byte, bool, error) {
+		if expr == "bad" {
+			return nil, false, &Diagnostic{Msg: "cannot evaluate"}
+		}
+		return []byte("<" + expr + ">"), strings.HasPrefix(expr, "x"), nil
+	})
+
+	ctx := newTestContext()
+	ctx.Registry = r
+	got, err := ctx.Convert([]byte("a{{ empty }}b {{x1}} {{ y }} {{ bad }} {{ x2 }} {{ unclosed"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "ab <x1> {{ y }} {{ bad }} <x2> {{ unclosed"
+	if string(got) != want {
+		t.Errorf("\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestRegistryConvert_WhitespaceControl(t *testing.T) {
+	a := &args.Args{
+		IncludeShortcodes: map[string]string{"note.html": "note"},
+		Site:              map[string]any{"baseurl": "/blog"},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "trimmed highlight",
+			input: "text\n\n{%- highlight python -%}\nprint(1)\n{%- endhighlight -%}\n\nafter",
+			want:  "text\n```python\nprint(1)\n```\nafter",
+		},
+		{
+			name:  "left trim only",
+			input: "text\n\n{%- highlight python %}\nx\n{% endhighlight %}\n\nafter",
+			want:  "text\n```python\nx\n```\n\nafter",
+		},
+		{
+			name:  "trimmed include",
+			input: "a   {%- include note.html -%}   b",
+			want:  "a{{ note() }}b",
+		},
+		{
+			name:  "trimmed include across lines",
+			input: "a\n{%- include note.html -%}\nb",
+			want:  "a{{ note() }}b",
+		},
+		{
+			name:  "trimmed output expression",
+			input: "[x]( {{- site.baseurl -}} /about)",
+			want:  "[x](/blog/about)",
+		},
+		{
+			name:  "unconverted tags keep surrounding whitespace",
+			input: "a {%- if x -%} b",
+			want:  "a {%- if x -%} b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewContext("/fake/post.md", a).Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package content
+
+import (
+	"bytes"
+	"slices"
+	"sort"
+)
+
+var (
+	commentOpen  = []byte("<!--")
+	commentClose = []byte("-->")
+)
+
+// scanSpans finds the regions of content that content passes must leave
+// alone: CommonMark code (fenced blocks, indented blocks and inline code
+// spans) and Liquid block tags registered in r, whose handlers deal with
+// their bodies. Both slices are sorted and do not overlap.
+func (r *Registry) scanSpans(content []byte) (code, blocks []span) {
+	var (
+		fence      []byte // opening fence run while inside a fenced block
+		fenceStart int
+		prevBlank  = true
+		prevCode   bool
+		inList     bool
+		midLine    bool // resuming right after a Liquid block that ended mid-line
+	)
+
+	off := 0
+	for off < len(content) {
+		lineEnd := bytes.IndexByte(content[off:], '\n')
+		next := len(content)
+		if lineEnd == -1 {
+			lineEnd = len(content)
+		} else {
+			lineEnd += off
+			next = lineEnd + 1
+		}
+		line := content[off:lineEnd]
+
+		if fence != nil {
+			if isClosingFence(line, fence) {
+				code = append(code, span{start: fenceStart, end: next})
+				fence = nil
+			}
+			off = next
+			continue
+		}
+
+		if !midLine {
+			indent, rest := lineIndent(line)
+			switch {
+			case len(bytes.TrimSpace(line)) == 0:
+				prevBlank = true
+				off = next
+				continue
+			case indent >= 4 && (prevBlank || prevCode) && !inList:
+				code = append(code, span{start: off, end: next})
+				prevBlank, prevCode = false, true
+				off = next
+				continue
+			case indent < 4 && openingFence(rest) != nil:
+				fence, fenceStart = openingFence(rest), off
+				prevBlank, prevCode = false, false
+				off = next
+				continue
+			case indent < 4:
+				if isListItem(rest) {
+					inList = true
+				} else if prevBlank {
+					inList = false
+				}
+			}
+		}
+		prevBlank, prevCode, midLine = false, false, false
+
+		resume := r.scanInline(content, off, lineEnd, &code, &blocks)
+		switch {
+		case resume <= lineEnd:
+			off = next
+		case resume < len(content) && content[resume] == '\n':
+			off = resume + 1
+		default:
+			off, midLine = resume, true
+		}
+	}
+
+	if fence != nil {
+		code = append(code, span{start: fenceStart, end: len(content)})
+	}
+	return code, blocks
+}
+
+// scanInline records the inline code spans and Liquid block tags that
+// start within content[start:end] and returns the offset at which
+// scanning should resume, which lies past end when a tag spans lines.
+func (r *Registry) scanInline(content []byte, start, end int, code, blocks *[]span) int {
+	i := start
+	for i < end {
+		switch {
+		case content[i] == '`':
+			n := backtickRun(content[i:end])
+			if closeIdx := findBacktickRun(content[i+n:end], n); closeIdx != -1 {
+				*code = append(*code, span{start: i, end: i + n + closeIdx + n})
+				i += n + closeIdx + n
+			} else {
+				i += n
+			}
+		case bytes.HasPrefix(content[i:], tagOpen):
+			tok, ok := nextTag(content, i)
+			if !ok || tok.start != i {
+				i += len(tagOpen)
+				continue
+			}
+			if spec, ok := r.tags[tok.name]; ok && spec.end != "" {
+				if endTok, ok := findEndTag(content, tok, spec.end, spec.literal); ok {
+					*blocks = append(*blocks, span{start: tok.start, end: endTok.end})
+					return endTok.end
+				}
+			}
+			i = tok.end
+		default:
+			i++
+		}
+	}
+	return i
+}
+
+// opaqueSpans returns the spans of content that the Kramdown passes leave
+// alone: code, as found by scanSpans, and HTML comments, whose text
+// Kramdown does not parse. The spans are sorted and do not overlap.
+func (c *Context) opaqueSpans(content []byte) []span {
+	code, _ := c.Registry.scanSpans(content)
+	if !bytes.Contains(content, commentOpen) {
+		return code
+	}
+
+	var comments []span
+	pos := 0
+	for {
+		i := bytes.Index(content[pos:], commentOpen)
+		if i == -1 {
+			break
+		}
+		start := pos + i
+		if s, ok := spanAt(code, start); ok {
+			pos = s.end
+			continue
+		}
+		j := bytes.Index(content[start+len(commentOpen):], commentClose)
+		if j == -1 {
+			break
+		}
+		pos = start + len(commentOpen) + j + len(commentClose)
+		comments = append(comments, span{start: start, end: pos})
+	}
+
+	code = slices.DeleteFunc(code, func(s span) bool {
+		_, ok := spanAt(comments, s.start)
+		return ok
+	})
+	return mergeSpans(code, comments)
+}
+
+// lineIndent returns the indentation width of line, expanding tabs to the
+// next multiple of four, and the line with the indentation removed.
+func lineIndent(line []byte) (int, []byte) {
+	width := 0
+	for i, c := range line {
+		switch c {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width, line[i:]
+		}
+	}
+	return width, nil
+}
+
+// openingFence returns the backtick or tilde run opening a fenced code
+// block, or nil if rest does not start one.
+func openingFence(rest []byte) []byte {
+	if len(rest) == 0 || (rest[0] != '`' && rest[0] != '~') {
+		return nil
+	}
+	n := 0
+	for n < len(rest) && rest[n] == rest[0] {
+		n++
+	}
+	if n < 3 {
+		return nil
+	}
+	if rest[0] == '`' && bytes.IndexByte(rest[n:], '`') != -1 {
+		return nil
+	}
+	return rest[:n]
+}
+
+// isClosingFence reports whether line closes a fenced block opened by fence.
+func isClosingFence(line, fence []byte) bool {
+	indent, rest := lineIndent(line)
+	if indent >= 4 {
+		return false
+	}
+	n := 0
+	for n < len(rest) && rest[n] == fence[0] {
+		n++
+	}
+	return n >= len(fence) && len(bytes.TrimSpace(rest[n:])) == 0
+}
+
+// isListItem reports whether rest starts with a list item marker.
+func isListItem(rest []byte) bool {
+	if len(rest) == 0 {
+		return false
+	}
+	n := 0
+	switch rest[0] {
+	case '-', '*', '+':
+		n = 1
+	default:
+		for n < len(rest) && n < 9 && rest[n] >= '0' && rest[n] <= '9' {
+			n++
+		}
+		if n == 0 || n >= len(rest) || (rest[n] != '.' && rest[n] != ')') {
+			return false
+		}
+		n++
+	}
+	return n == len(rest) || rest[n] == ' ' || rest[n] == '\t'
+}
+
+// backtickRun returns the length of the backtick run at the start of b.
+func backtickRun(b []byte) int {
+	n := 0
+	for n < len(b) && b[n] == '`' {
+		n++
+	}
+	return n
+}
+
+// findBacktickRun returns the offset of the first run of exactly n
+// backticks in b, or -1.
+func findBacktickRun(b []byte, n int) int {
+	for i := 0; i < len(b); {
+		if b[i] != '`' {
+			i++
+			continue
+		}
+		run := backtickRun(b[i:])
+		if run == n {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
+
+// spanAt returns the span in the sorted spans that contains offset pos.
+func spanAt(spans []span, pos int) (span, bool) {
+	i := sort.Search(len(spans), func(i int) bool { return spans[i].end > pos })
+	if i < len(spans) && spans[i].start <= pos {
+		return spans[i], true
+	}
+	return span{}, false
+}
@@ -0,0 +1,139 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/en9inerd/j2z/internal/args"
+)
+
+func TestConvert_SkipsCode(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "backtick fence",
+			input: "```markdown\n{% highlight ruby %}\nx\n{% endhighlight %}\n<!-- more -->\n```\n",
+			want:  "```markdown\n{% highlight ruby %}\nx\n{% endhighlight %}\n<!-- more -->\n```\n",
+		},
+		{
+			name:  "tilde fence",
+			input: "~~~\n{% post_url 2024-01-01-x %}\n~~~\n{% highlight go %}\nx\n{% endhighlight %}",
+			want:  "~~~\n{% post_url 2024-01-01-x %}\n~~~\n```go\nx\n```",
+		},
+		{
+			name:  "nested fences",
+			input: "````markdown\n```go\n{% highlight go %}\n```\n{% endhighlight %}\n````\nafter<!-- more -->",
+			want:  "````markdown\n```go\n{% highlight go %}\n```\n{% endhighlight %}\n````\nafter\n<!--more-->",
+		},
+		{
+			name:  "tilde fence not closed by backticks",
+			input: "~~~\n```\n{{ site.baseurl }}\n~~~\n{{ site.baseurl }}",
+			want:  "~~~\n```\n{{ site.baseurl }}\n~~~\n",
+		},
+		{
+			name:  "indented fence is still a fence",
+			input: "   ```\n   <!-- more -->\n   ```\n",
+			want:  "   ```\n   <!-- more -->\n   ```\n",
+		},
+		{
+			name:  "unclosed fence runs to end",
+			input: "```\n{{ site.baseurl }}\n",
+			want:  "```\n{{ site.baseurl }}\n",
+		},
+		{
+			name:  "indented code block",
+			input: "text\n\n    {% highlight ruby %}\n    x\n    {% endhighlight %}\n\n{{ site.baseurl }}",
+			want:  "text\n\n    {% highlight ruby %}\n    x\n    {% endhighlight %}\n\n",
+		},
+		{
+			name:  "indented paragraph continuation is prose",
+			input: "text\n    {{ site.baseurl }}/a",
+			want:  "text\n    /a",
+		},
+		{
+			name:  "indented list continuation is prose",
+			input: "- item\n\n    {{ site.baseurl }}/a",
+			want:  "- item\n\n    /a",
+		},
+		{
+			name:  "tab indented code block",
+			input: "\t<!-- more -->\n",
+			want:  "\t<!-- more -->\n",
+		},
+		{
+			name:  "inline code span",
+			input: "Write `{% raw %}` or ``{{ site.baseurl }}`` but {{ site.baseurl }}/x",
+			want:  "Write `{% raw %}` or ``{{ site.baseurl }}`` but /x",
+		},
+		{
+			name:  "unmatched backticks are literal",
+			input: "a ``b` {{ site.baseurl }}/x",
+			want:  "a ``b` /x",
+		},
+		{
+			name:  "highlight body with fence markers does not leak",
+			input: "{% highlight markdown %}\n```go\n{% endhighlight %}\n{{ site.baseurl }}/x",
+			want:  "```markdown\n```go\n```\n/x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestContext().Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanSpans(t *testing.T) {
+	content := []byte("a `b` c\n\n```\nd\n```\n{% raw %}e{% endraw %}\n")
+	code, blocks := builtinRegistry(&args.Args{}).scanSpans(content)
+
+	wantCode := []span{{start: 2, end: 5}, {start: 9, end: 19}}
+	if len(code) != len(wantCode) {
+		t.Fatalf("got code spans %v, want %v", code, wantCode)
+	}
+	for i := range code {
+		if code[i] != wantCode[i] {
+			t.Errorf("code[%d] = %v, want %v", i, code[i], wantCode[i])
+		}
+	}
+
+	wantBlocks := []span{{start: 19, end: 41}}
+	if len(blocks) != 1 || blocks[0] != wantBlocks[0] {
+		t.Errorf("got block spans %v, want %v", blocks, wantBlocks)
+	}
+}
+
+func TestIsListItem(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"- item", true},
+		{"* item", true},
+		{"+ item", true},
+		{"1. item", true},
+		{"12) item", true},
+		{"-", true},
+		{"-item", false},
+		{"1.item", false},
+		{"text", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := isListItem([]byte(tt.input)); got != tt.want {
+				t.Errorf("isListItem(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package content
+
+import "bytes"
+
+var mathDelim = []byte("$$")
+
+// markdownSpecial lists the characters inside math that markdown would
+// otherwise interpret, such as _ and * as emphasis.
+const markdownSpecial = "\\_*`[]<>~|"
+
+// convertMath rewrites Kramdown $$ math outside code and HTML comments
+// into KaTeX delimiters, \\( \\) for inline and \\[ \\] for display math,
+// escaping the markdown punctuation inside it, or into the configured
+// math shortcode. Following Kramdown, $$ math is display math when it
+// forms a paragraph of its own and inline math otherwise.
+func (c *Context) convertMath(content []byte) []byte {
+	if !bytes.Contains(content, mathDelim) {
+		return content
+	}
+	code := c.opaqueSpans(content)
+
+	var result []byte
+	pos, open := 0, -1
+	for i := 0; i+len(mathDelim) <= len(content); {
+		if s, ok := spanAt(code, i); ok {
+			open = -1 // math cannot contain code
+			i = s.end
+			continue
+		}
+		if !bytes.HasPrefix(content[i:], mathDelim) || (i > 0 && content[i-1] == '\\') {
+			i++
+			continue
+		}
+		if open == -1 || isBlankLineIn(content[open+len(mathDelim):i]) {
+			open = i
+			i += len(mathDelim)
+			continue
+		}
+
+		end := i + len(mathDelim)
+		body := content[open+len(mathDelim) : i]
+		display := startsBlock(content, open) && endsBlock(content, end)
+		result = append(result, content[pos:open]...)
+		result = append(result, c.mathMarkup(body, display)...)
+		pos, open, i = end, -1, end
+		if c.Args.MathFlag != "" {
+			c.SetExtra(c.Args.MathFlag, true)
+		}
+	}
+	if result == nil {
+		return content
+	}
+	return append(result, content[pos:]...)
+}
+
+// mathMarkup formats the math body in the configured target format.
+func (c *Context) mathMarkup(body []byte, display bool) []byte {
+	var result []byte
+	if name := c.Args.MathShortcode; name != "" {
+		args := "()"
+		if display {
+			args = "(display=true)"
+		}
+		result = append(result, "{% "+name+args+" %}"...)
+		result = append(result, body...)
+		return append(result, "{% end %}"...)
+	}
+
+	open, close := `\\(`, `\\)`
+	if display {
+		open, close = `\\[`, `\\]`
+	}
+	result = append(result, open...)
+	result = append(result, escapeMarkdown(body)...)
+	return append(result, close...)
+}
+
+// escapeMarkdown backslash-escapes the characters in b that markdown
+// would interpret, so that the rendered text matches b.
+func escapeMarkdown(b []byte) []byte {
+	var result []byte
+	for _, ch := range b {
+		if bytes.IndexByte([]byte(markdownSpecial), ch) != -1 {
+			result = append(result, '\\')
+		}
+		result = append(result, ch)
+	}
+	return result
+}
+
+// isBlankLineIn reports whether b contains a blank line, which ends a
+// paragraph and so cannot occur inside math.
+func isBlankLineIn(b []byte) bool {
+	lines := bytes.Split(b, []byte("\n"))
+	for _, line := range lines[1:max(len(lines)-1, 1)] {
+		if len(bytes.TrimSpace(line)) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// startsBlock reports whether offset i is the start of a paragraph: at
+// the start of a line that follows a blank line or the start of content.
+func startsBlock(content []byte, i int) bool {
+	lineStart := bytes.LastIndexByte(content[:i], '\n') + 1
+	if len(bytes.TrimSpace(content[lineStart:i])) != 0 {
+		return false
+	}
+	if lineStart == 0 {
+		return true
+	}
+	prevStart := bytes.LastIndexByte(content[:lineStart-1], '\n') + 1
+	return len(bytes.TrimSpace(content[prevStart:lineStart])) == 0
+}
+
+// endsBlock reports whether offset i is the end of a paragraph: at the
+// end of a line that is followed by a blank line or the end of content.
+func endsBlock(content []byte, i int) bool {
+	lineEnd := bytes.IndexByte(content[i:], '\n')
+	if lineEnd == -1 {
+		return len(bytes.TrimSpace(content[i:])) == 0
+	}
+	if len(bytes.TrimSpace(content[i:i+lineEnd])) != 0 {
+		return false
+	}
+	rest := content[i+lineEnd+1:]
+	next := bytes.IndexByte(rest, '\n')
+	if next == -1 {
+		next = len(rest)
+	}
+	return len(bytes.TrimSpace(rest[:next])) == 0
+}
@@ -0,0 +1,106 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/en9inerd/j2z/internal/args"
+)
+
+func TestConvertMath(t *testing.T) {
+	tests := []struct {
+		name      string
+		shortcode string
+		input     string
+		want      string
+		wantFlag  bool
+	}{
+		{
+			name:     "inline math",
+			input:    "The sum $$a_1 + a_2$$ is small.\n",
+			want:     `The sum \\(a\_1 + a\_2\\) is small.` + "\n",
+			wantFlag: true,
+		},
+		{
+			name:     "display math",
+			input:    "Text.\n\n$$\n\\sum_{i=1}^n x_i^*\n$$\n\nMore.\n",
+			want:     "Text.\n\n\\\\[\n\\\\sum\\_{i=1}^n x\\_i^\\*\n\\\\]\n\nMore.\n",
+			wantFlag: true,
+		},
+		{
+			name:     "single line display math",
+			input:    "$$E = mc^2$$\n",
+			want:     `\\[E = mc^2\\]` + "\n",
+			wantFlag: true,
+		},
+		{
+			name:     "math at paragraph start followed by text is inline",
+			input:    "$$x$$ is a variable.\n",
+			want:     `\\(x\\) is a variable.` + "\n",
+			wantFlag: true,
+		},
+		{
+			name:     "backslashes doubled",
+			input:    `$$a \\ b \{c\} <d>$$ ok`,
+			want:     `\\(a \\\\ b \\{c\\} \<d\>\\) ok`,
+			wantFlag: true,
+		},
+		{
+			name:      "shortcode",
+			shortcode: "math",
+			input:     "Inline $$x_1$$.\n\n$$\ny_2\n$$\n",
+			want:      "Inline {% math() %}x_1{% end %}.\n\n{% math(display=true) %}\ny_2\n{% end %}\n",
+			wantFlag:  true,
+		},
+		{
+			name:  "escaped delimiter",
+			input: `Costs \$$5 and \$$6.`,
+			want:  `Costs \$$5 and \$$6.`,
+		},
+		{
+			name:  "delimiters across paragraphs",
+			input: "Price $$5\n\nand $$6.\n",
+			want:  "Price $$5\n\nand $$6.\n",
+		},
+		{
+			name:  "code untouched",
+			input: "`$$x_1$$` and\n\n```\n$$y$$\n```\n",
+			want:  "`$$x_1$$` and\n\n```\n$$y$$\n```\n",
+		},
+		{
+			name:  "HTML comment untouched",
+			input: "<!-- TODO $$x_1$$ -->\n",
+			want:  "<!-- TODO $$x_1$$ -->\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := NewContext("/fake/post.md", &args.Args{MathFlag: "math", MathShortcode: tt.shortcode})
+			got, err := ctx.Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+			if flag := ctx.Extra["math"] == true; flag != tt.wantFlag {
+				t.Errorf("math flag = %v, want %v", flag, tt.wantFlag)
+			}
+		})
+	}
+}
+
+func TestConvertMath_CommentTag(t *testing.T) {
+	ctx := NewContext("/fake/post.md", &args.Args{MathFlag: "math", Comments: args.CommentsHTML})
+	input := "{% comment %}\nTODO $$x_1$$\n{: .hidden}\n{% endcomment %}"
+	got, err := ctx.Convert([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "<!--\nTODO $$x_1$$\n{: .hidden}\n-->"; string(got) != want {
+		t.Errorf("\ngot:  %q\nwant: %q", got, want)
+	}
+	if _, ok := ctx.Extra["math"]; ok {
+		t.Error("math flag set for math inside a comment")
+	}
+}
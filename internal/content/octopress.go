@@ -0,0 +1,252 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// registerOctopress adds the handlers for the tags Octopress shipped on
+// top of Jekyll.
+func registerOctopress(r *Registry) {
+	r.RegisterBlock("codeblock", "endcodeblock", codeblockTag)
+	r.RegisterBlock("blockquote", "endblockquote", blockquoteTag)
+	r.RegisterBlock("pullquote", "endpullquote", pullquoteTag)
+	r.Register("img", imgTag)
+}
+
+// pullquoteMarker matches the {" ... "} marker around the pulled text.
+var pullquoteMarker = regexp.MustCompile(`\{"\s*(.+?)\s*"\}`)
+
+// codeblockTag converts {% codeblock [title] [lang:x] [url [link text]] %}
+// into a fenced code block preceded by a bold caption line.
+func codeblockTag(ctx *Context, tag *Tag) ([]byte, error) {
+	var (
+		lang, url string
+		info      []string
+		title     []string
+		linkText  []string
+	)
+	for _, f := range strings.Fields(tag.Args) {
+		key, value, _ := strings.Cut(f, ":")
+		switch {
+		case key == "lang" && value != "":
+			lang = value
+		case key == "start" && value != "":
+			info = append(info, "linenostart="+value)
+		case key == "mark" && value != "":
+			if ranges, ok := lineRanges(value); ok {
+				info = append(info, "hl_lines="+ranges)
+			}
+		case key == "linenos":
+			if value != "false" {
+				info = append(info, "linenos")
+			}
+		case url == "" && (isAbsoluteURL(f) || strings.HasPrefix(f, "/")):
+			url = f
+		case url != "":
+			linkText = append(linkText, f)
+		default:
+			title = append(title, f)
+		}
+	}
+
+	caption := strings.Join(title, " ")
+	if lang == "" && caption != "" {
+		// Octopress guesses the language from a file name in the title.
+		lang = strings.TrimPrefix(path.Ext(caption), ".")
+	}
+	if lang != "" {
+		info = append([]string{ctx.zolaLanguage(lang)}, info...)
+	}
+
+	code, err := ctx.Registry.convertLiteral(ctx, tag.Body)
+	if err != nil {
+		return nil, err
+	}
+	code = bytes.TrimPrefix(code, []byte("\n"))
+	code = bytes.TrimSuffix(code, []byte("\n"))
+
+	var result []byte
+	if caption != "" || url != "" {
+		if caption != "" {
+			result = fmt.Appendf(result, "**%s**", caption)
+		}
+		if url != "" {
+			text := strings.Join(linkText, " ")
+			if text == "" {
+				text = "link"
+			}
+			if caption != "" {
+				result = append(result, ' ')
+			}
+			result = fmt.Appendf(result, "([%s](%s))", text, url)
+		}
+		result = append(result, "\n\n"...)
+	}
+	result = append(result, "```"...)
+	result = append(result, strings.Join(info, ",")...)
+	result = append(result, '\n')
+	result = append(result, code...)
+	result = append(result, "\n```"...)
+	return result, nil
+}
+
+// blockquoteTag converts {% blockquote [author[, source]] [url [link title]] %}
+// into a markdown blockquote ending with an attribution line.
+func blockquoteTag(ctx *Context, tag *Tag) ([]byte, error) {
+	body, err := ctx.convertLiquid(bytes.TrimSpace(tag.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		url      string
+		byline   []string
+		linkText []string
+	)
+	for _, f := range strings.Fields(tag.Args) {
+		switch {
+		case url == "" && isAbsoluteURL(f):
+			url = f
+		case url != "":
+			linkText = append(linkText, f)
+		default:
+			byline = append(byline, f)
+		}
+	}
+
+	author, source, _ := strings.Cut(strings.Join(byline, " "), ", ")
+	if source == "" {
+		source = strings.Join(linkText, " ")
+	}
+	if url != "" {
+		if source == "" {
+			source = strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://")
+		}
+		source = fmt.Sprintf("[%s](%s)", source, url)
+	}
+
+	var attribution []string
+	for _, part := range []string{author, source} {
+		if part != "" {
+			attribution = append(attribution, part)
+		}
+	}
+
+	var result []byte
+	for i, line := range bytes.Split(body, []byte("\n")) {
+		if i > 0 {
+			result = append(result, '\n')
+		}
+		result = append(result, '>')
+		if len(line) > 0 {
+			result = append(result, ' ')
+			result = append(result, line...)
+		}
+	}
+	if len(attribution) > 0 {
+		result = append(result, "\n>\n> — "...)
+		result = append(result, strings.Join(attribution, ", ")...)
+	}
+	return result, nil
+}
+
+// imgTag converts {% img [class] src [width [height]] ["title" ["alt"]] %}
+// into a markdown image, or an HTML image when classes or a size are given.
+func imgTag(_ *Context, tag *Tag) ([]byte, error) {
+	fields := splitFields(tag.Args)
+
+	srcIdx := -1
+	for i, f := range fields {
+		if isAbsoluteURL(f) || strings.Contains(f, "/") {
+			srcIdx = i
+			break
+		}
+	}
+	if srcIdx == -1 {
+		return nil, &Diagnostic{Msg: "missing image source"}
+	}
+
+	classes := fields[:srcIdx]
+	src := fields[srcIdx]
+	rest := fields[srcIdx+1:]
+
+	var size []string
+	for len(rest) > 0 && len(size) < 2 {
+		if _, err := strconv.Atoi(rest[0]); err != nil {
+			break
+		}
+		size, rest = append(size, rest[0]), rest[1:]
+	}
+
+	var title, alt string
+	switch {
+	case len(rest) == 2 && isQuoted(rest[0]) && isQuoted(rest[1]):
+		title, alt = unquote(rest[0]), unquote(rest[1])
+	case len(rest) > 0:
+		title = unquote(strings.Join(rest, " "))
+		alt = title
+	}
+
+	if len(classes) == 0 && len(size) == 0 {
+		result := fmt.Appendf(nil, "![%s](%s", alt, src)
+		if title != "" {
+			result = fmt.Appendf(result, " %q", title)
+		}
+		return append(result, ')'), nil
+	}
+
+	attrs := []string{}
+	if len(classes) > 0 {
+		attrs = append(attrs, fmt.Sprintf(`class="%s"`, html.EscapeString(strings.Join(classes, " "))))
+	}
+	attrs = append(attrs, fmt.Sprintf(`src="%s"`, html.EscapeString(src)))
+	if len(size) > 0 {
+		attrs = append(attrs, fmt.Sprintf(`width="%s"`, size[0]))
+	}
+	if len(size) > 1 {
+		attrs = append(attrs, fmt.Sprintf(`height="%s"`, size[1]))
+	}
+	if title != "" {
+		attrs = append(attrs, fmt.Sprintf(`title="%s"`, html.EscapeString(title)))
+	}
+	attrs = append(attrs, fmt.Sprintf(`alt="%s"`, html.EscapeString(alt)))
+	return fmt.Appendf(nil, "<img %s>", strings.Join(attrs, " ")), nil
+}
+
+// pullquoteTag converts {% pullquote [left] %} ... {" text "} ... {% endpullquote %}
+// into the span Octopress rendered, with the pulled text in data-pullquote.
+func pullquoteTag(ctx *Context, tag *Tag) ([]byte, error) {
+	m := pullquoteMarker.FindSubmatchIndex(tag.Body)
+	if m == nil {
+		return nil, &Diagnostic{Msg: `pullquote without {" "} marker`}
+	}
+	quote := tag.Body[m[2]:m[3]]
+
+	var text []byte
+	text = append(text, tag.Body[:m[0]]...)
+	text = append(text, quote...)
+	text = append(text, tag.Body[m[1]:]...)
+	text, err := ctx.convertLiquid(bytes.TrimSpace(text))
+	if err != nil {
+		return nil, err
+	}
+
+	side := "right"
+	if strings.TrimSpace(tag.Args) == "left" {
+		side = "left"
+	}
+	return fmt.Appendf(nil, `<span class="pullquote-%s" data-pullquote="%s">%s</span>`,
+		side, html.EscapeString(string(quote)), text), nil
+}
+
+// isQuoted reports whether s is a single or double quoted string.
+func isQuoted(s string) bool {
+	_, ok := stringLiteral(s)
+	return ok
+}
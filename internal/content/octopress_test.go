@@ -0,0 +1,104 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/en9inerd/j2z/internal/args"
+)
+
+func TestOctopressTags(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "codeblock with lang",
+			input: "{% codeblock lang:ruby %}\nputs 1\n{% endcodeblock %}",
+			want:  "```ruby\nputs 1\n```",
+		},
+		{
+			name:  "codeblock with title url and link text",
+			input: "{% codeblock Time to be Awesome - awesome.rb http://example.com/awesome.rb download it %}\nputs 1\n{% endcodeblock %}",
+			want:  "**Time to be Awesome - awesome.rb** ([download it](http://example.com/awesome.rb))\n\n```ruby\nputs 1\n```",
+		},
+		{
+			name:  "codeblock with raw region",
+			input: "{% codeblock lang:liquid %}\n{% raw %}{{ page.title }}{% endraw %}\n{% endcodeblock %}",
+			want:  "```html\n{{/* page.title */}}\n```",
+		},
+		{
+			name:  "codeblock with options",
+			input: "{% codeblock lang:python start:5 mark:6,7 %}\nx\n{% endcodeblock %}",
+			want:  "```python,linenostart=5,hl_lines=6-7\nx\n```",
+		},
+		{
+			name:  "blockquote with author and source",
+			input: "{% blockquote Seth Godin, Welcome to Island Marketing %}\nEvery interaction is both precious and an opportunity.\n{% endblockquote %}",
+			want:  "> Every interaction is both precious and an opportunity.\n>\n> — Seth Godin, Welcome to Island Marketing",
+		},
+		{
+			name:  "blockquote with url and link title",
+			input: "{% blockquote @allanbranch https://twitter.com/allanbranch/status/1 Twitter %}\nQuote one.\n\nQuote two.\n{% endblockquote %}",
+			want:  "> Quote one.\n>\n> Quote two.\n>\n> — @allanbranch, [Twitter](https://twitter.com/allanbranch/status/1)",
+		},
+		{
+			name:  "blockquote without attribution",
+			input: "{% blockquote %}\nJust a quote.\n{% endblockquote %}",
+			want:  "> Just a quote.",
+		},
+		{
+			name:  "blockquote math converted once",
+			input: "{% blockquote %}\nSee $$x_1$$.\n{% endblockquote %}",
+			want:  "> See \\\\(x\\_1\\\\).",
+		},
+		{
+			name:  "plain img becomes markdown image",
+			input: `{% img /images/cat.png "A cat" "cat picture" %}`,
+			want:  `![cat picture](/images/cat.png "A cat")`,
+		},
+		{
+			name:  "img with class and size becomes html",
+			input: `{% img left http://placekitten.com/320/250 200 100 "Place Kitten" "a kitten" %}`,
+			want:  `<img class="left" src="http://placekitten.com/320/250" width="200" height="100" title="Place Kitten" alt="a kitten">`,
+		},
+		{
+			name:  "img without source left in place",
+			input: "{% img left %}",
+			want:  "{% img left %}",
+		},
+		{
+			name:  "pullquote",
+			input: "{% pullquote %}\nSurprisingly {\" pull quotes are easy \"} to make.\n{% endpullquote %}",
+			want:  `<span class="pullquote-right" data-pullquote="pull quotes are easy">Surprisingly pull quotes are easy to make.</span>`,
+		},
+		{
+			name:  "pullquote left",
+			input: "{% pullquote left %}a {\" b \"} c{% endpullquote %}",
+			want:  `<span class="pullquote-left" data-pullquote="b">a b c</span>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewContext("/fake/post.md", &args.Args{Octopress: true}).Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOctopressTags_Disabled(t *testing.T) {
+	input := "{% img /images/cat.png %}"
+	got, err := newTestContext().Convert([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != input {
+		t.Errorf("Octopress tags should be left alone without --octopress, got %q", got)
+	}
+}
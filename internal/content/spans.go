@@ -0,0 +1,38 @@
+package content
+
+// span is a half-open byte range [start, end) of content.
+type span struct {
+	start, end int
+}
+
+// mergeSpans merges two sorted, mutually non-overlapping span lists.
+func mergeSpans(a, b []span) []span {
+	merged := make([]span, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if a[0].start < b[0].start {
+			merged, a = append(merged, a[0]), a[1:]
+		} else {
+			merged, b = append(merged, b[0]), b[1:]
+		}
+	}
+	merged = append(merged, a...)
+	return append(merged, b...)
+}
+
+// mapOutside applies fn to each part of content that lies outside the
+// given sorted, non-overlapping spans, copying the spans themselves
+// unchanged.
+func mapOutside(content []byte, spans []span, fn func([]byte) []byte) []byte {
+	if len(spans) == 0 {
+		return fn(content)
+	}
+
+	var result []byte
+	pos := 0
+	for _, s := range spans {
+		result = append(result, fn(content[pos:s.start])...)
+		result = append(result, content[s.start:s.end]...)
+		pos = s.end
+	}
+	return append(result, fn(content[pos:])...)
+}
@@ -0,0 +1,281 @@
+package content
+
+import (
+	"bytes"
+	"strings"
+)
+
+// convertTables normalizes Kramdown tables outside code and HTML comments
+// into GFM pipe tables. Kramdown tables may lack a header separator row
+// and leading pipes, use + junctions or = footer separators, and have
+// rows with differing cell counts; pulldown-cmark renders none of these.
+// Tables that are already valid GFM are left as they are.
+func (c *Context) convertTables(content []byte) []byte {
+	if !bytes.Contains(content, []byte("|")) {
+		return content
+	}
+	code := c.opaqueSpans(content)
+
+	var (
+		result   = make([]byte, 0, len(content))
+		pos      int
+		rows     [][]byte
+		start    int    // offset of the first row in rows
+		boundary = true // a table starts a block, as in Kramdown
+		nest     blockContext
+	)
+	flush := func(end int) {
+		if len(rows) > 0 && !isGFMTable(rows) {
+			result = append(result, content[pos:start]...)
+			result = append(result, gfmTable(rows)...)
+			pos = end
+		}
+		rows = nil
+	}
+	for off := 0; off < len(content); {
+		next := len(content)
+		if i := bytes.IndexByte(content[off:], '\n'); i != -1 {
+			next = off + i + 1
+		}
+		kind := lineKind(content, off, next, code)
+		s, ok := spanAt(code, off)
+		nested := false
+		if kind != blockCode && (!ok || s.start == off) {
+			nested = nest.nested(content[off:next])
+		}
+		if row, ok := tableRow(content, off, next, code); ok && !nested && (len(rows) > 0 || boundary) {
+			if len(rows) == 0 {
+				start = off
+			}
+			rows = append(rows, row)
+		} else {
+			flush(off)
+			boundary = kind != blockText
+		}
+		off = next
+	}
+	flush(len(content))
+	return append(result, content[pos:]...)
+}
+
+// blockContext tracks whether lines belong to a blockquote or list, which
+// Kramdown parses before looking for tables. A blockquote ends at a blank
+// line and a list at the first unindented line after one that starts no
+// item, so that tables indented under an item stay in the list.
+type blockContext struct {
+	quote, list, prevBlank bool
+}
+
+// nested records line and reports whether it lies in a blockquote or list.
+func (b *blockContext) nested(line []byte) bool {
+	indent, rest := lineIndent(line)
+	rest = bytes.TrimRight(rest, " \t\r\n")
+	switch {
+	case len(rest) == 0:
+		b.prevBlank = true
+		return false
+	case indent < 4 && rest[0] == '>':
+		b.quote = true
+	case indent < 4 && isListItem(rest):
+		b.list = true
+	case b.prevBlank:
+		b.quote = false
+		b.list = b.list && indent > 0
+	}
+	b.prevBlank = false
+	return b.quote || b.list
+}
+
+// tableRow returns the table row on the line content[start:end] with its
+// indentation and trailing whitespace removed. Following Kramdown, a row
+// starts with a pipe or contains a pipe that is neither escaped nor in
+// code. Pipes in Liquid left in place, which Jekyll would have rendered
+// before Kramdown, do not count.
+func tableRow(content []byte, start, end int, code []span) ([]byte, bool) {
+	if s, ok := spanAt(code, start); (ok && s.start < start) || lineKind(content, start, end, code) != blockText {
+		return nil, false
+	}
+	line := bytes.TrimRight(content[start:end], "\r\n")
+	indent, rest := lineIndent(line)
+	if indent >= 4 {
+		return nil, false
+	}
+	rest = bytes.TrimRight(rest, " \t")
+	if rest[0] == '|' {
+		return rest, true
+	}
+	lineEnd := start + len(line)
+	for i := start; i < lineEnd; i++ {
+		if s, ok := spanAt(code, i); ok {
+			i = s.end - 1
+			continue
+		}
+		switch content[i] {
+		case '\\':
+			i++
+		case '{':
+			if tok, ok := nextTag(content[:lineEnd], i); ok && tok.start == i {
+				i = tok.end - 1
+			}
+		case '|':
+			return rest, true
+		}
+	}
+	return nil, false
+}
+
+// isGFMTable reports whether rows already form a GFM table: a header row
+// followed by a plain delimiter row with the same number of cells and no
+// further separator rows.
+func isGFMTable(rows [][]byte) bool {
+	if len(rows) < 2 || !isSeparatorRow(rows[1]) || bytes.ContainsAny(rows[1], "+=") {
+		return false
+	}
+	if len(splitCells(rows[0])) != len(splitCells(rows[1])) {
+		return false
+	}
+	for _, row := range rows[2:] {
+		if isSeparatorRow(row) {
+			return false
+		}
+	}
+	return true
+}
+
+// gfmTable formats Kramdown table rows as a GFM table. The rows before
+// the first separator form the header; without one an empty header is
+// synthesized. Column alignment is taken from the first separator.
+func gfmTable(rows [][]byte) []byte {
+	var (
+		header []string
+		body   [][]string
+		align  []string
+	)
+	sepIdx := -1
+	for i, row := range rows {
+		if isSeparatorRow(row) {
+			sepIdx = i
+			break
+		}
+	}
+	if sepIdx != -1 {
+		align = separatorAlignment(rows[sepIdx])
+	}
+
+	for i, row := range rows {
+		if isSeparatorRow(row) {
+			continue
+		}
+		cells := splitCells(row)
+		if i < sepIdx && header == nil {
+			header = cells
+			continue
+		}
+		body = append(body, cells)
+	}
+
+	cols := max(len(header), len(align))
+	for _, row := range body {
+		cols = max(cols, len(row))
+	}
+	if header == nil {
+		header = make([]string, cols)
+	}
+
+	var sb strings.Builder
+	writeRow := func(cells []string) {
+		sb.WriteString("|")
+		for i := range cols {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			sb.WriteString(" " + cell + " |")
+		}
+		sb.WriteString("\n")
+	}
+
+	writeRow(header)
+	sb.WriteString("|")
+	for i := range cols {
+		a := "---"
+		if i < len(align) {
+			a = align[i]
+		}
+		sb.WriteString(" " + a + " |")
+	}
+	sb.WriteString("\n")
+	for _, row := range body {
+		writeRow(row)
+	}
+	return []byte(sb.String())
+}
+
+// isSeparatorRow reports whether row is a Kramdown separator line such as
+// |---|:--:|, |--+--| or the |=== footer separator.
+func isSeparatorRow(row []byte) bool {
+	trimmed := bytes.Trim(row, "| \t")
+	return len(trimmed) > 0 && bytes.ContainsAny(trimmed, "-=") &&
+		len(bytes.Trim(trimmed, "|+-=: \t")) == 0
+}
+
+// separatorAlignment returns the GFM delimiter cell for each column of a
+// separator row.
+func separatorAlignment(row []byte) []string {
+	var align []string
+	fields := strings.FieldsFunc(strings.Trim(string(row), "| \t"), func(r rune) bool {
+		return r == '|' || r == '+'
+	})
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		switch {
+		case strings.HasPrefix(f, ":") && strings.HasSuffix(f, ":") && len(f) > 1:
+			align = append(align, ":---:")
+		case strings.HasPrefix(f, ":"):
+			align = append(align, ":---")
+		case strings.HasSuffix(f, ":"):
+			align = append(align, "---:")
+		default:
+			align = append(align, "---")
+		}
+	}
+	return align
+}
+
+// splitCells splits a table row into trimmed cells. Pipes that are
+// escaped or inside code spans do not separate cells; those inside code
+// spans are escaped so that GFM keeps them in the cell.
+func splitCells(row []byte) []string {
+	row = bytes.TrimPrefix(row, []byte("|"))
+	var (
+		cells []string
+		cell  []byte
+	)
+	for i := 0; i < len(row); i++ {
+		switch ch := row[i]; {
+		case ch == '\\' && i+1 < len(row):
+			cell = append(cell, ch, row[i+1])
+			i++
+		case ch == '`':
+			n := backtickRun(row[i:])
+			end := findBacktickRun(row[i+n:], n)
+			if end == -1 {
+				cell = append(cell, row[i:i+n]...)
+				i += n - 1
+				continue
+			}
+			span := row[i : i+n+end+n]
+			cell = append(cell, bytes.ReplaceAll(span, []byte("|"), []byte(`\|`))...)
+			i += len(span) - 1
+		case ch == '|':
+			cells = append(cells, strings.TrimSpace(string(cell)))
+			cell = nil
+		default:
+			cell = append(cell, ch)
+		}
+	}
+	if rest := strings.TrimSpace(string(cell)); rest != "" {
+		cells = append(cells, rest)
+	}
+	return cells
+}
@@ -0,0 +1,52 @@
+package content
+
+import (
+	"bytes"
+
+	"github.com/en9inerd/j2z/internal/args"
+)
+
+// builtinRegistry returns a registry with all tag and output handlers
+// shipped with j2z that are enabled by a.
+func builtinRegistry(a *args.Args) *Registry {
+	r := NewRegistry()
+	r.RegisterLiteralBlock("raw", "endraw", rawTag)
+	r.RegisterBlock("comment", "endcomment", commentTag)
+	r.RegisterBlock("highlight", "endhighlight", highlightTag)
+	r.Register("include", includeTag)
+	r.Register("include_relative", includeRelativeTag)
+	r.Register("post_url", postURLTag)
+	r.Register("link", linkTag)
+	r.Register("gist", gistTag)
+	r.Register("youtube", videoTag)
+	r.Register("vimeo", videoTag)
+	r.Register("twitter", tweetTag)
+	r.Register("tweet", tweetTag)
+	r.RegisterOutput(urlOutput)
+	r.RegisterOutput(variableOutput)
+	if a.Octopress {
+		registerOctopress(r)
+	}
+	return r
+}
+
+// rawTag drops the {% raw %} markers and escapes the Liquid inside them so
+// that Zola does not interpret it as shortcodes.
+func rawTag(_ *Context, tag *Tag) ([]byte, error) {
+	return escapeLiquid(tag.Body), nil
+}
+
+// commentTag removes {% comment %} blocks or, with the html comment policy,
+// turns them into HTML comments whose Liquid is escaped.
+func commentTag(ctx *Context, tag *Tag) ([]byte, error) {
+	if ctx.Args.Comments != args.CommentsHTML {
+		return []byte{}, nil
+	}
+	body := bytes.ReplaceAll(escapeLiquid(tag.Body), []byte("-->"), []byte("-- >"))
+
+	var result []byte
+	result = append(result, "<!--"...)
+	result = append(result, body...)
+	result = append(result, "-->"...)
+	return result, nil
+}
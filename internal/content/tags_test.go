@@ -0,0 +1,133 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/en9inerd/j2z/internal/args"
+)
+
+func TestRawTag(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "markers dropped and liquid escaped",
+			input: "Use {% raw %}{{ page.title }}{% endraw %} in templates.",
+			want:  "Use {{/* page.title */}} in templates.",
+		},
+		{
+			name:  "tags inside raw are not converted",
+			input: "{% raw %}\n{% highlight ruby %}\nx\n{% endhighlight %}\n{% include a.html %}\n{% endraw %}",
+			want:  "\n{%/* highlight ruby */%}\nx\n{%/* endhighlight */%}\n{%/* include a.html */%}\n",
+		},
+		{
+			name:  "raw ends at first endraw",
+			input: "{% raw %}{% raw %}{% endraw %} after",
+			want:  "{%/* raw */%} after",
+		},
+		{
+			name:  "more tag inside raw untouched",
+			input: "{% raw %}a<!-- more -->b{% endraw %}c<!-- more -->",
+			want:  "a<!-- more -->bc\n<!--more-->",
+		},
+		{
+			name:  "conversion continues after raw",
+			input: "{% raw %}{% highlight go %}{% endraw %}\n{% highlight go %}\nx\n{% endhighlight %}",
+			want:  "{%/* highlight go */%}\n```go\nx\n```",
+		},
+		{
+			name:  "raw inside backtick fence",
+			input: "```liquid\n{% raw %}\n{{ page.title }}\n{% endraw %}\n```\n",
+			want:  "```html\n\n{{/* page.title */}}\n\n```\n",
+		},
+		{
+			name:  "raw inside tilde fence with whitespace control",
+			input: "~~~\n{%- raw -%}\n{% if x %}\n{%- endraw -%}\n~~~\n",
+			want:  "~~~\n{%/* if x */%}\n~~~\n",
+		},
+		{
+			name:  "raw inside inline code",
+			input: "Use `{% raw %}{{ x }}{% endraw %}` here.",
+			want:  "Use `{{/* x */}}` here.",
+		},
+		{
+			name:  "raw inside highlight",
+			input: "{% highlight liquid %}{% raw %}{{ x }}{% endraw %}{% endhighlight %}",
+			want:  "```html\n{{/* x */}}\n```",
+		},
+		{
+			name:  "other liquid inside code left alone",
+			input: "```\n{% include a.html %} {% raw %}{{ x }}{% endraw %}\n```\n",
+			want:  "```\n{% include a.html %} {{/* x */}}\n```\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestContext().Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommentTag(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		input  string
+		want   string
+	}{
+		{
+			name:  "dropped by default",
+			input: "a{% comment %}hidden {% include x.html %}{% endcomment %}b",
+			want:  "ab",
+		},
+		{
+			name:   "explicit drop",
+			policy: args.CommentsDrop,
+			input:  "a\n{%- comment -%}\nhidden\n{%- endcomment -%}\nb",
+			want:   "a\nb",
+		},
+		{
+			name:   "html comment with escaped liquid",
+			policy: args.CommentsHTML,
+			input:  "{% comment %} see {{ page.title }} and {% post_url x %} {% endcomment %}",
+			want:   "<!-- see {{/* page.title */}} and {%/* post_url x */%} -->",
+		},
+		{
+			name:   "html comment terminator neutralized",
+			policy: args.CommentsHTML,
+			input:  "{% comment %}a --> b{% endcomment %}",
+			want:   "<!--a -- > b-->",
+		},
+		{
+			name:  "nested comments",
+			input: "{% comment %}a{% comment %}b{% endcomment %}c{% endcomment %}d",
+			want:  "d",
+		},
+		{
+			name:  "more tag inside comment untouched",
+			input: "{% comment %}<!-- more -->{% endcomment %}",
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewContext("/fake/post.md", &args.Args{Comments: tt.policy}).Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
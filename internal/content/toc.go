@@ -0,0 +1,45 @@
+package content
+
+import (
+	"bytes"
+	"slices"
+)
+
+// convertTOC removes Kramdown table of contents markers (a list followed
+// by a {:toc} IAL, usually "* TOC") outside code and HTML comments. It
+// sets the configured [extra] flag so templates can render page.toc, and
+// puts the configured shortcode in the marker's place.
+func (c *Context) convertTOC(content []byte) []byte {
+	if !bytes.Contains(content, []byte("toc")) {
+		return content
+	}
+	code := c.opaqueSpans(content)
+	blocks := splitBlocks(content, code)
+
+	result := make([]byte, 0, len(content))
+	pos := 0
+	for i, b := range blocks {
+		if b.kind != blockIAL || i == 0 || blocks[i-1].kind != blockText {
+			continue
+		}
+		list := blocks[i-1]
+		if _, rest := lineIndent(content[list.start:list.end]); !isListItem(rest) {
+			continue
+		}
+		line := bytes.TrimSpace(content[b.start:b.end])
+		if aldLine.Match(line) || !slices.Contains(parseIAL(string(line[2:len(line)-1])).refs, "toc") {
+			continue
+		}
+
+		result = append(result, content[pos:list.start]...)
+		if name := c.Args.TOCShortcode; name != "" {
+			result = append(result, shortcodeCall(name, nil)...)
+			result = append(result, '\n')
+		}
+		pos = b.end
+		if c.Args.TOCFlag != "" {
+			c.SetExtra(c.Args.TOCFlag, true)
+		}
+	}
+	return append(result, content[pos:]...)
+}
@@ -0,0 +1,68 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/en9inerd/j2z/internal/args"
+)
+
+func TestConvertTOC(t *testing.T) {
+	tests := []struct {
+		name      string
+		shortcode string
+		input     string
+		want      string
+		wantFlag  bool
+	}{
+		{
+			name:     "marker removed",
+			input:    "Intro.\n\n* TOC\n{:toc}\n\n## One\n",
+			want:     "Intro.\n\n\n## One\n",
+			wantFlag: true,
+		},
+		{
+			name:     "marker at start",
+			input:    "* TOC\n{:toc}\n",
+			want:     "",
+			wantFlag: true,
+		},
+		{
+			name:      "shortcode inserted",
+			shortcode: "toc",
+			input:     "{:.no_toc}\n## Contents\n\n1. this list is replaced\n{:toc}\n\nText.\n",
+			want:      "## Contents {.no_toc}\n\n{{ toc() }}\n\nText.\n",
+			wantFlag:  true,
+		},
+		{
+			name:  "other list ial untouched",
+			input: "* a\n{: .compact}\n",
+			want:  "<div class=\"compact\">\n\n* a\n\n</div>\n",
+		},
+		{
+			name:  "inside code untouched",
+			input: "```\n* TOC\n{:toc}\n```\n",
+			want:  "```\n* TOC\n{:toc}\n```\n",
+		},
+		{
+			name:  "inside HTML comment untouched",
+			input: "<!--\n* TOC\n{:toc}\n-->\n",
+			want:  "<!--\n* TOC\n{:toc}\n-->\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := NewContext("/fake/post.md", &args.Args{TOCFlag: "toc", TOCShortcode: tt.shortcode})
+			got, err := ctx.Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+			if flag := ctx.Extra["toc"] == true; flag != tt.wantFlag {
+				t.Errorf("toc flag = %v, want %v", flag, tt.wantFlag)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package content
+
+import (
+	"fmt"
+	"strings"
+)
+
+// urlOutput evaluates the URL expressions Jekyll sites use to build links:
+// {{ site.baseurl }}, {{ site.url }} and string literals piped through the
+// relative_url, absolute_url, append and prepend filters. When
+// Args.URLShortcode is set, relative_url and absolute_url are emitted as a
+// call of that shortcode so the page can use Zola's get_url.
+func urlOutput(ctx *Context, expr string) ([]byte, bool, error) {
+	e, err := parseExpression(expr)
+	if err != nil {
+		return nil, false, nil
+	}
+
+	var value string
+	switch e.value {
+	case "site.baseurl":
+		value = siteString(ctx, "baseurl")
+	case "site.url":
+		value = siteString(ctx, "url")
+	default:
+		lit, ok := stringLiteral(e.value)
+		if !ok || len(e.filters) == 0 {
+			return nil, false, nil
+		}
+		value = lit
+	}
+
+	for i, f := range e.filters {
+		switch f.name {
+		case "relative_url", "absolute_url":
+			if ctx.Args.URLShortcode != "" && i == len(e.filters)-1 && !isAbsoluteURL(value) {
+				path := quoteShortcodeArg(strings.TrimPrefix(value, "/"))
+				return shortcodeCall(ctx.Args.URLShortcode, []string{"path=" + path}), true, nil
+			}
+			value = relativeURL(ctx, value)
+			if f.name == "absolute_url" && !isAbsoluteURL(value) {
+				value = strings.TrimSuffix(siteString(ctx, "url"), "/") + value
+			}
+		case "append", "prepend":
+			if len(f.args) != 1 {
+				return nil, false, nil
+			}
+			arg, ok := stringLiteral(f.args[0])
+			if !ok {
+				return nil, false, nil
+			}
+			if f.name == "append" {
+				value += arg
+			} else {
+				value = arg + value
+			}
+		default:
+			return nil, false, nil
+		}
+	}
+
+	return []byte(value), true, nil
+}
+
+// isSiteURLOutput reports whether the output expression expr yields only
+// the site's base URL, as {{ site.baseurl }}, {{ site.url }} and
+// {{ "/" | relative_url }} do. Jekyll sites put these in front of
+// {% post_url %} and {% link %}, whose Zola internal links must stand
+// alone.
+func isSiteURLOutput(expr string) bool {
+	e, err := parseExpression(expr)
+	if err != nil {
+		return false
+	}
+	for _, f := range e.filters {
+		if f.name != "relative_url" && f.name != "absolute_url" {
+			return false
+		}
+	}
+	switch e.value {
+	case "site.baseurl", "site.url":
+		return true
+	}
+	lit, ok := stringLiteral(e.value)
+	return ok && len(e.filters) > 0 && strings.Trim(lit, "/") == ""
+}
+
+// relativeURL mirrors Jekyll's relative_url filter: it prefixes path with
+// the site's baseurl, making sure the result starts with a slash.
+func relativeURL(ctx *Context, path string) string {
+	if isAbsoluteURL(path) {
+		return path
+	}
+	base := strings.Trim(siteString(ctx, "baseurl"), "/")
+	path = strings.TrimPrefix(path, "/")
+	if base == "" {
+		return "/" + path
+	}
+	if path == "" {
+		return "/" + base
+	}
+	return "/" + base + "/" + path
+}
+
+// isAbsoluteURL reports whether s carries a URL scheme or is protocol-relative.
+func isAbsoluteURL(s string) bool {
+	return strings.HasPrefix(s, "//") || strings.Contains(s, "://")
+}
+
+// siteString returns a string value from the Jekyll site configuration.
+func siteString(ctx *Context, key string) string {
+	v, ok := lookup(ctx.Args.Site, key)
+	if !ok || v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,100 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/en9inerd/j2z/internal/args"
+)
+
+func TestURLOutput(t *testing.T) {
+	site := map[string]any{"url": "https://example.com", "baseurl": "/blog"}
+
+	tests := []struct {
+		name      string
+		site      map[string]any
+		shortcode string
+		input     string
+		want      string
+	}{
+		{
+			name:  "baseurl prefix on image",
+			site:  site,
+			input: "![img]({{ site.baseurl }}/assets/img.png)",
+			want:  "![img](/blog/assets/img.png)",
+		},
+		{
+			name:  "site url",
+			site:  site,
+			input: "{{ site.url }}{{ site.baseurl }}/feed.xml",
+			want:  "https://example.com/blog/feed.xml",
+		},
+		{
+			name:  "relative_url filter",
+			site:  site,
+			input: `[about]({{ "/about" | relative_url }})`,
+			want:  "[about](/blog/about)",
+		},
+		{
+			name:  "relative_url without leading slash",
+			site:  site,
+			input: `{{ 'about' | relative_url }}`,
+			want:  "/blog/about",
+		},
+		{
+			name:  "absolute_url filter",
+			site:  site,
+			input: `{{ "/about" | absolute_url }}`,
+			want:  "https://example.com/blog/about",
+		},
+		{
+			name:  "append before relative_url",
+			site:  site,
+			input: `{{ "/assets" | append: "/img.png" | relative_url }}`,
+			want:  "/blog/assets/img.png",
+		},
+		{
+			name:  "absolute input left untouched by relative_url",
+			site:  site,
+			input: `{{ "https://other.org/x" | relative_url }}`,
+			want:  "https://other.org/x",
+		},
+		{
+			name:  "empty baseurl",
+			site:  map[string]any{},
+			input: "{{ site.baseurl }}/assets/img.png and {{ \"/about\" | relative_url }}",
+			want:  "/assets/img.png and /about",
+		},
+		{
+			name:      "shortcode mode",
+			site:      site,
+			shortcode: "url",
+			input:     `[about]({{ "/about" | relative_url }})`,
+			want:      `[about]({{ url(path="about") }})`,
+		},
+		{
+			name:  "unrelated expression left in place",
+			site:  site,
+			input: "{{ page.title | upcase }}",
+			want:  "{{ page.title | upcase }}",
+		},
+		{
+			name:  "plain literal left in place",
+			site:  site,
+			input: `{{ "text" }}`,
+			want:  `{{ "text" }}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &args.Args{Site: tt.site, URLShortcode: tt.shortcode}
+			got, err := NewContext("/fake/post.md", a).Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
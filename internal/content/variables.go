@@ -0,0 +1,323 @@
+package content
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// liquidTimeFormat is the format Liquid uses when printing a date without
+// a date filter.
+const liquidTimeFormat = "2006-01-02 15:04:05 -0700"
+
+// variableOutput substitutes {{ page.* }} and {{ site.* }} references with
+// their values from the page's front matter and the Jekyll site
+// configuration, applying the common Liquid filters. References that
+// cannot be resolved are reported and left in place.
+func variableOutput(ctx *Context, expr string) ([]byte, bool, error) {
+	e, err := parseExpression(expr)
+	if err != nil {
+		return nil, false, nil
+	}
+	if !isVariableRef(e.value) {
+		if _, ok := stringLiteral(e.value); !ok || len(e.filters) == 0 {
+			return nil, false, nil
+		}
+	}
+
+	value, found := ctx.resolve(e.value)
+	if !found && !slices.ContainsFunc(e.filters, func(f filter) bool { return f.name == "default" }) {
+		return nil, false, &Diagnostic{Msg: "unresolved Liquid variable"}
+	}
+	for _, f := range e.filters {
+		if value, err = ctx.applyFilter(value, f); err != nil {
+			return nil, false, err
+		}
+	}
+
+	s, err := liquidString(value)
+	if err != nil {
+		return nil, false, err
+	}
+	return []byte(s), true, nil
+}
+
+// isVariableRef reports whether s refers to a page or site variable.
+func isVariableRef(s string) bool {
+	return strings.HasPrefix(s, "page.") || strings.HasPrefix(s, "site.")
+}
+
+// resolve evaluates a literal or a page/site variable reference. It
+// reports false when the reference does not resolve.
+func (c *Context) resolve(s string) (any, bool) {
+	if lit, ok := stringLiteral(s); ok {
+		return lit, true
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n, true
+	}
+
+	var (
+		v  any
+		ok bool
+	)
+	switch {
+	case strings.HasPrefix(s, "page."):
+		v, ok = lookup(c.Page, strings.TrimPrefix(s, "page."))
+	case strings.HasPrefix(s, "site."):
+		v, ok = lookup(c.Args.Site, strings.TrimPrefix(s, "site."))
+	}
+	return v, ok
+}
+
+// applyFilter applies a single Liquid filter to value.
+func (c *Context) applyFilter(value any, f filter) (any, error) {
+	args := make([]any, len(f.args))
+	for i, a := range f.args {
+		v, ok := c.resolve(a)
+		if !ok {
+			return nil, &Diagnostic{Msg: "unresolved Liquid variable in filter argument"}
+		}
+		args[i] = v
+	}
+	arg := func(i int) (string, error) {
+		if i >= len(args) {
+			return "", &Diagnostic{Msg: fmt.Sprintf("missing argument for %s filter", f.name)}
+		}
+		return liquidString(args[i])
+	}
+
+	switch f.name {
+	case "date", "date_to_string", "date_to_long_string", "date_to_xmlschema", "date_to_rfc822":
+		t, ok := toTime(value)
+		if !ok {
+			return nil, &Diagnostic{Msg: "date filter applied to a non-date value"}
+		}
+		switch f.name {
+		case "date":
+			format, err := arg(0)
+			if err != nil {
+				return nil, err
+			}
+			return strftime(t, format), nil
+		case "date_to_string":
+			return strftime(t, "%d %b %Y"), nil
+		case "date_to_long_string":
+			return strftime(t, "%d %B %Y"), nil
+		case "date_to_xmlschema":
+			return t.Format(time.RFC3339), nil
+		default:
+			return t.Format(time.RFC1123Z), nil
+		}
+	case "default":
+		if value == nil || value == "" || value == false {
+			return arg(0)
+		}
+		return value, nil
+	case "join":
+		list, ok := value.([]any)
+		if !ok {
+			return value, nil
+		}
+		sep, err := arg(0)
+		if err != nil {
+			return nil, err
+		}
+		parts := make([]string, len(list))
+		for i, item := range list {
+			if parts[i], err = liquidString(item); err != nil {
+				return nil, err
+			}
+		}
+		return strings.Join(parts, sep), nil
+	case "size":
+		switch v := value.(type) {
+		case []any:
+			return len(v), nil
+		case string:
+			return len(v), nil
+		}
+		return 0, nil
+	}
+
+	s, err := liquidString(value)
+	if err != nil {
+		return nil, err
+	}
+	switch f.name {
+	case "upcase":
+		return strings.ToUpper(s), nil
+	case "downcase":
+		return strings.ToLower(s), nil
+	case "capitalize":
+		if s == "" {
+			return s, nil
+		}
+		return strings.ToUpper(s[:1]) + strings.ToLower(s[1:]), nil
+	case "escape", "xml_escape":
+		return html.EscapeString(s), nil
+	case "strip":
+		return strings.TrimSpace(s), nil
+	case "slugify":
+		return Slugify(s), nil
+	case "append", "prepend":
+		a, err := arg(0)
+		if err != nil {
+			return nil, err
+		}
+		if f.name == "append" {
+			return s + a, nil
+		}
+		return a + s, nil
+	case "relative_url":
+		return relativeURL(c, s), nil
+	case "absolute_url":
+		u := relativeURL(c, s)
+		if !isAbsoluteURL(u) {
+			u = strings.TrimSuffix(siteString(c, "url"), "/") + u
+		}
+		return u, nil
+	}
+	return nil, &Diagnostic{Msg: fmt.Sprintf("unsupported Liquid filter %q", f.name)}
+}
+
+// liquidString renders a value the way Liquid prints it.
+func liquidString(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case time.Time:
+		return v.Format(liquidTimeFormat), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []any:
+		var sb strings.Builder
+		for _, item := range v {
+			s, err := liquidString(item)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(s)
+		}
+		return sb.String(), nil
+	case map[string]any:
+		return "", &Diagnostic{Msg: "Liquid variable refers to a map"}
+	}
+	return fmt.Sprint(v), nil
+}
+
+// toTime converts a front matter date value into a time.
+func toTime(v any) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range []string{time.RFC3339, liquidTimeFormat, time.DateTime, time.DateOnly} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// Slugify lowercases s and replaces every run of non-alphanumeric
+// characters with a single hyphen, like Jekyll's default slugify mode.
+func Slugify(s string) string {
+	var sb strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			sb.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+	return sb.String()
+}
+
+// strftime formats t using the strftime directives supported by Liquid's
+// date filter. A "-" flag (as in %-d) removes zero padding.
+func strftime(t time.Time, format string) string {
+	var sb strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			sb.WriteByte(format[i])
+			continue
+		}
+		i++
+		unpadded := false
+		if format[i] == '-' && i+1 < len(format) {
+			unpadded = true
+			i++
+		}
+		pad := func(n, width int) string {
+			if unpadded {
+				return strconv.Itoa(n)
+			}
+			return fmt.Sprintf("%0*d", width, n)
+		}
+
+		switch format[i] {
+		case 'Y':
+			sb.WriteString(strconv.Itoa(t.Year()))
+		case 'y':
+			sb.WriteString(pad(t.Year()%100, 2))
+		case 'm':
+			sb.WriteString(pad(int(t.Month()), 2))
+		case 'd':
+			sb.WriteString(pad(t.Day(), 2))
+		case 'e':
+			sb.WriteString(fmt.Sprintf("%2d", t.Day()))
+		case 'j':
+			sb.WriteString(pad(t.YearDay(), 3))
+		case 'H':
+			sb.WriteString(pad(t.Hour(), 2))
+		case 'I':
+			h := t.Hour() % 12
+			if h == 0 {
+				h = 12
+			}
+			sb.WriteString(pad(h, 2))
+		case 'M':
+			sb.WriteString(pad(t.Minute(), 2))
+		case 'S':
+			sb.WriteString(pad(t.Second(), 2))
+		case 'p':
+			sb.WriteString(t.Format("PM"))
+		case 'B':
+			sb.WriteString(t.Month().String())
+		case 'b', 'h':
+			sb.WriteString(t.Format("Jan"))
+		case 'A':
+			sb.WriteString(t.Weekday().String())
+		case 'a':
+			sb.WriteString(t.Format("Mon"))
+		case 'Z':
+			sb.WriteString(t.Format("MST"))
+		case 'z':
+			sb.WriteString(t.Format("-0700"))
+		case 's':
+			sb.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case '%':
+			sb.WriteByte('%')
+		default:
+			sb.WriteByte('%')
+			if unpadded {
+				sb.WriteByte('-')
+			}
+			sb.WriteByte(format[i])
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,70 @@
+package content
+
+import (
+	"testing"
+	"time"
+
+	"github.com/en9inerd/j2z/internal/args"
+)
+
+func TestVariableOutput(t *testing.T) {
+	page := map[string]any{
+		"title":  "hello world",
+		"date":   time.Date(2024, 3, 5, 9, 7, 0, 0, time.UTC),
+		"tags":   []any{"go", "zola"},
+		"author": map[string]any{"name": "Ann"},
+		"empty":  "",
+		"count":  3,
+		"html":   "<b>&</b>",
+		"padded": "  x  ",
+	}
+	site := map[string]any{"title": "My Blog", "baseurl": "/blog"}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "page variable", input: "# {{ page.title }}", want: "# hello world"},
+		{name: "nested page variable", input: "by {{ page.author.name }}", want: "by Ann"},
+		{name: "site variable", input: "{{ site.title }}", want: "My Blog"},
+		{name: "number", input: "{{ page.count }}", want: "3"},
+		{name: "array", input: "{{ page.tags }}", want: "gozola"},
+		{name: "upcase", input: "{{ page.title | upcase }}", want: "HELLO WORLD"},
+		{name: "downcase", input: "{{ site.title | downcase }}", want: "my blog"},
+		{name: "capitalize", input: "{{ page.title | capitalize }}", want: "Hello world"},
+		{name: "escape", input: "{{ page.html | escape }}", want: "&lt;b&gt;&amp;&lt;/b&gt;"},
+		{name: "strip", input: "[{{ page.padded | strip }}]", want: "[x]"},
+		{name: "default on empty", input: `{{ page.empty | default: "none" }}`, want: "none"},
+		{name: "default on missing key", input: `{{ page.subtitle | default: site.title }}`, want: "My Blog"},
+		{name: "default with unresolved argument", input: `{{ page.subtitle | default: site.nope }}`, want: "{{ page.subtitle | default: site.nope }}"},
+		{name: "append and prepend", input: `{{ page.title | append: "!" | prepend: "> " }}`, want: "> hello world!"},
+		{name: "join", input: `{{ page.tags | join: ", " }}`, want: "go, zola"},
+		{name: "date", input: `{{ page.date | date: "%B %-d, %Y %H:%M" }}`, want: "March 5, 2024 09:07"},
+		{name: "date padded", input: `{{ page.date | date: "%Y-%m-%d %a %b %e" }}`, want: "2024-03-05 Tue Mar  5"},
+		{name: "date_to_string", input: "{{ page.date | date_to_string }}", want: "05 Mar 2024"},
+		{name: "date_to_long_string", input: "{{ page.date | date_to_long_string }}", want: "05 March 2024"},
+		{name: "date_to_xmlschema", input: "{{ page.date | date_to_xmlschema }}", want: "2024-03-05T09:07:00Z"},
+		{name: "date string", input: `{{ "2024-01-02" | date: "%d/%m" }}`, want: "02/01"},
+		{name: "relative_url on variable", input: "{{ page.title | slugify | relative_url }}", want: "/blog/hello-world"},
+		{name: "unresolved page variable", input: "{{ page.url }}", want: "{{ page.url }}"},
+		{name: "unsupported filter", input: "{{ page.title | truncatewords: 2 }}", want: "{{ page.title | truncatewords: 2 }}"},
+		{name: "map value", input: "{{ page.author }}", want: "{{ page.author }}"},
+		{name: "other variables untouched", input: "{{ post.title | upcase }}", want: "{{ post.title | upcase }}"},
+		{name: "inside code", input: "`{{ page.title }}`", want: "`{{ page.title }}`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := NewContext("/fake/post.md", &args.Args{Site: site})
+			ctx.Page = page
+			got, err := ctx.Convert([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -38,3 +38,20 @@ type DateError struct {
 func (e *DateError) Error() string {
 	return fmt.Sprintf("date error in %s: could not parse %q: %s", e.File, e.Value, e.Reason)
 }
+
+// IncludeError represents a failure to inline an {% include_relative %} target.
+type IncludeError struct {
+	File   string
+	Target string
+	Msg    string
+	Err    error
+}
+
+func (e *IncludeError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("include error in %s: %q: %s: %v", e.File, e.Target, e.Msg, e.Err)
+	}
+	return fmt.Sprintf("include error in %s: %q: %s", e.File, e.Target, e.Msg)
+}
+
+func (e *IncludeError) Unwrap() error { return e.Err }
@@ -4,18 +4,18 @@ import (
 	"bytes"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"path"
+	"path/filepath"
 	"slices"
 	"strings"
 	"time"
 
-	"github.com/BurntSushi/toml"
 	"github.com/en9inerd/j2z/internal/args"
 	"github.com/en9inerd/j2z/internal/content"
 	"github.com/en9inerd/j2z/internal/errs"
 	"github.com/en9inerd/j2z/internal/frontmatter"
-	"gopkg.in/yaml.v3"
 )
 
 // rootFrontMatterKeys lists the keys Zola recognizes at the top level of
@@ -49,6 +49,7 @@ var dateFormats = []string{
 type MarkdownFile interface {
 	Load() error
 	ProcessFrontMatter() error
+	ConvertContent(args *args.Args) error
 	ConvertToTOML(args *args.Args) error
 	Save(args *args.Args) error
 }
@@ -58,10 +59,22 @@ type JekyllMarkdownFile struct {
 	Path        string
 	Content     []byte
 	FrontMatter []byte
+	// Body is the converted markdown body, set by ConvertContent.
+	Body []byte
+	// Extra holds the values content passes add to the [extra] table,
+	// such as flags for pages with a table of contents.
+	Extra map[string]any
+	// ModTime is the modification time of the file, set by Load. It
+	// dates drafts that have no date of their own.
+	ModTime time.Time
 }
 
 func (f *JekyllMarkdownFile) Load() error {
-	var err error
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return err
+	}
+	f.ModTime = info.ModTime()
 	f.Content, err = os.ReadFile(f.Path)
 	return err
 }
@@ -75,11 +88,53 @@ func (f *JekyllMarkdownFile) ProcessFrontMatter() error {
 	return nil
 }
 
+// ConvertContent converts the markdown body, resolving {{ page.* }}
+// references against the front matter.
+func (f *JekyllMarkdownFile) ConvertContent(a *args.Args) error {
+	data, err := f.frontMatterData(a)
+	if err != nil {
+		return err
+	}
+
+	ctx := content.NewContext(f.Path, a)
+	ctx.Page = data.toMap()
+	body := frontmatter.Strip(bytes.Clone(f.Content))
+	if f.Body, err = ctx.Convert(body); err != nil {
+		return err
+	}
+	f.Extra = ctx.Extra
+	return nil
+}
+
+// frontMatterData parses the YAML front matter in source key order,
+// resolving the date field in the configured timezone.
+func (f *JekyllMarkdownFile) frontMatterData(a *args.Args) (*orderedMap, error) {
+	data, err := parseYAMLOrdered(f.FrontMatter)
+	if err != nil {
+		return nil, err
+	}
+
+	if v, _ := data.Get("date"); v != nil {
+		if dateStr, ok := v.(string); ok {
+			t, err := parseDate(dateStr, a.Tz)
+			if err != nil {
+				return nil, &errs.DateError{File: f.Path, Value: dateStr, Reason: "unrecognized format"}
+			}
+			data.Set("date", t)
+		}
+	}
+	return data, nil
+}
+
+// ConvertToTOML maps the Jekyll front matter onto Zola's front matter
+// structure and serializes it as TOML, or as YAML when a selects the
+// YAML output format.
 func (f *JekyllMarkdownFile) ConvertToTOML(a *args.Args) error {
-	var data map[string]any
-	if err := yaml.Unmarshal(f.FrontMatter, &data); err != nil {
+	data, err := f.frontMatterData(a)
+	if err != nil {
 		return err
 	}
+	placement := f.applyRules(a, data)
 
 	if a.Aliases {
 		year, month, day, slug, err := parseJekyllFilename(path.Base(f.Path))
@@ -87,68 +142,150 @@ func (f *JekyllMarkdownFile) ConvertToTOML(a *args.Args) error {
 			return err
 		}
 		alias := fmt.Sprintf("%s/%s/%s/%s", year, month, day, slug)
-		data["aliases"] = []string{alias}
+		data.Set("aliases", []string{alias})
 	}
 
-	if dateStr, ok := data["date"].(string); ok {
-		t, err := parseDate(dateStr, a.Tz)
-		if err != nil {
-			return &errs.DateError{File: f.Path, Value: dateStr, Reason: "unrecognized format"}
-		}
-		data["date"] = t
-	}
+	f.mapDraft(a, data)
+	f.mapPermalink(a, data)
 
 	// Map Jekyll's last_modified_at to Zola's updated field.
-	if modifiedAt, ok := data["last_modified_at"]; ok {
-		if dateStr, ok := modifiedAt.(string); ok {
+	if modifiedAt, ok := data.Get("last_modified_at"); ok {
+		updated, ok := modifiedAt.(time.Time)
+		if dateStr, isString := modifiedAt.(string); isString {
 			t, err := parseDate(dateStr, a.Tz)
-			if err == nil {
-				data["updated"] = t
-			}
+			updated, ok = t, err == nil
+		}
+		if ok {
+			data.Set("last_modified_at", updated)
+			data.Rename("last_modified_at", "updated")
+		} else {
+			data.Delete("last_modified_at")
 		}
-		delete(data, "last_modified_at")
 	}
 
 	effectiveRootKeys := slices.Concat(rootFrontMatterKeys, a.ExtraRootKeys)
 
-	extra := make(map[string]any)
-	taxonomies := make(map[string]any)
+	extra := newOrderedMap()
+	taxonomies := newOrderedMap()
 
-	for key, value := range data {
-		if slices.Contains(a.Taxonomies, key) {
-			taxonomies[key] = value
-			delete(data, key)
-			continue
+	for _, key := range data.Keys() {
+		value, _ := data.Get(key)
+		switch place := placement[key]; {
+		case place == placeRoot:
+		case place == placeTaxonomies, place == "" && slices.Contains(a.Taxonomies, key):
+			taxonomies.Set(key, value)
+			data.Delete(key)
+		case place == placeExtra, !slices.Contains(effectiveRootKeys, key):
+			extra.Set(key, value)
+			data.Delete(key)
 		}
-		if !slices.Contains(effectiveRootKeys, key) {
-			extra[key] = value
-			delete(data, key)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(f.Extra)) {
+		if _, ok := extra.Get(key); !ok {
+			extra.Set(key, f.Extra[key])
 		}
 	}
 
-	if len(extra) > 0 {
-		data["extra"] = extra
+	if taxonomies.Len() > 0 {
+		data.Set("taxonomies", taxonomies)
 	}
-	if len(taxonomies) > 0 {
-		data["taxonomies"] = taxonomies
+	if extra.Len() > 0 {
+		data.Set("extra", extra)
 	}
 
-	tomlBytes, err := toml.Marshal(data)
-	if err != nil {
+	if a.FrontMatterFormat == args.FormatYAML {
+		f.FrontMatter, err = encodeYAML(data)
 		return err
 	}
-
-	f.FrontMatter = stripLeadingWhitespace(tomlBytes)
+	f.FrontMatter = encodeTOML(data)
 	return nil
 }
 
+// mapDraft maps Jekyll's published: false to Zola's draft = true and marks
+// every page under _drafts as a draft. Drafts without a date are dated
+// with a.DraftDate when it is set, else with the date prefix of their
+// filename or, failing that, the file modification time, as Jekyll does.
+func (f *JekyllMarkdownFile) mapDraft(a *args.Args, data *orderedMap) {
+	draft := false
+	if rel, err := filepath.Rel(a.JekyllDir, f.Path); err == nil && a.JekyllDir != "" {
+		first, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+		draft = first == "_drafts"
+	}
+
+	if published, ok := data.Get("published"); ok {
+		if published == false {
+			draft = true
+		}
+		data.Delete("published")
+	}
+	if !draft {
+		return
+	}
+	data.Set("draft", true)
+
+	if v, _ := data.Get("date"); v != nil {
+		return
+	}
+	date := a.DraftDate
+	if date.IsZero() {
+		if year, month, day, _, err := parseJekyllFilename(path.Base(f.Path)); err == nil {
+			date, _ = time.ParseInLocation(time.DateOnly, year+"-"+month+"-"+day, a.Tz)
+		}
+	}
+	if date.IsZero() {
+		date = f.ModTime.Truncate(time.Second)
+	}
+	if date.IsZero() {
+		return
+	}
+	slog.Debug("dating draft", "file", f.Path, "date", date)
+	data.Set("date", date.In(a.Tz))
+}
+
+// mapPermalink maps a per-page Jekyll permalink to Zola's path so the page
+// keeps its URL or, with args.PermalinkAlias, to an alias redirecting the
+// old URL to Zola's default one. Permalinks with placeholders such as
+// :title are left in [extra] with a warning.
+func (f *JekyllMarkdownFile) mapPermalink(a *args.Args, data *orderedMap) {
+	v, _ := data.Get("permalink")
+	permalink, ok := v.(string)
+	if !ok {
+		return
+	}
+	normalized := normalizePermalink(permalink)
+	if strings.Contains(permalink, ":") || normalized == "" {
+		slog.Warn("permalink cannot be mapped, keeping it in [extra]", "file", f.Path, "permalink", permalink)
+		return
+	}
+
+	if a.Permalinks != args.PermalinkAlias {
+		data.Set("permalink", normalized)
+		data.Rename("permalink", "path")
+		return
+	}
+
+	var aliases []any
+	existing, _ := data.Get("aliases")
+	switch v := existing.(type) {
+	case []string:
+		for _, s := range v {
+			aliases = append(aliases, s)
+		}
+	case []any:
+		aliases = v
+	}
+	data.Delete("permalink")
+	data.Set("aliases", append(aliases, strings.Trim(permalink, "/")))
+}
+
 func (f *JekyllMarkdownFile) Save(a *args.Args) error {
 	outputFilePath, outputDirPath, err := getOutputPaths(f.Path, &a.JekyllDir, &a.ZolaDir)
 	if err != nil {
 		return err
 	}
 
-	combined := content.CombineFrontMatterAndContent(f.FrontMatter, f.Content)
+	combined := content.CombineFrontMatterAndContent(f.FrontMatter, f.Body, content.NewContext(f.Path, a))
 
 	if a.DryRun {
 		slog.Info("dry-run: would write", "path", outputFilePath, "size", len(combined))
@@ -189,6 +326,15 @@ func parseDate(dateStr string, tz *time.Location) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("could not parse date: %s", dateStr)
 }
 
+// normalizePermalink turns a Jekyll permalink such as "/talks/my-talk/" or
+// "/talks/my-talk.html" into a Zola path like "talks/my-talk".
+func normalizePermalink(permalink string) string {
+	p := strings.TrimSpace(permalink)
+	p = strings.TrimSuffix(p, "index.html")
+	p = strings.TrimSuffix(p, ".html")
+	return strings.Trim(p, "/")
+}
+
 // stripLeadingWhitespace removes leading spaces/tabs from every line.
 func stripLeadingWhitespace(data []byte) []byte {
 	lines := bytes.Split(data, []byte("\n"))
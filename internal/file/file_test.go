@@ -1,12 +1,16 @@
 package file
 
 import (
+	"maps"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/en9inerd/j2z/internal/args"
 )
 
@@ -226,6 +230,49 @@ func TestConvertToTOML_ExtraKeys(t *testing.T) {
 	}
 }
 
+func TestConvertContent(t *testing.T) {
+	f := &JekyllMarkdownFile{
+		Path:        "/fake/2024-01-01-test.md",
+		Content:     []byte("---\ntitle: Test\n---\n\n# {{ page.title }}\n\nBody.\n"),
+		FrontMatter: []byte("title: Test"),
+	}
+
+	if err := f.ConvertContent(&args.Args{Tz: time.UTC}); err != nil {
+		t.Fatalf("ConvertContent failed: %v", err)
+	}
+	if strings.Contains(string(f.Body), "---") {
+		t.Error("body should not contain YAML delimiters")
+	}
+	if want := "\n\n# Test\n\nBody.\n"; string(f.Body) != want {
+		t.Errorf("\ngot:  %q\nwant: %q", f.Body, want)
+	}
+}
+
+func TestConvertContent_Extra(t *testing.T) {
+	f := &JekyllMarkdownFile{
+		Path:        "/fake/2024-01-01-test.md",
+		Content:     []byte("---\ntitle: Test\n---\n\n# {{ page.title }}\n\n* TOC\n{:toc}\n\nBody.\n"),
+		FrontMatter: []byte("title: Test"),
+	}
+
+	a := &args.Args{Tz: time.UTC, TOCFlag: "toc"}
+
+	if err := f.ConvertContent(a); err != nil {
+		t.Fatalf("ConvertContent failed: %v", err)
+	}
+	if want := "\n\n# Test\n\n\nBody.\n"; string(f.Body) != want {
+		t.Errorf("\ngot:  %q\nwant: %q", f.Body, want)
+	}
+
+	if err := f.ConvertToTOML(a); err != nil {
+		t.Fatalf("ConvertToTOML failed: %v", err)
+	}
+	result := string(f.FrontMatter)
+	if !strings.Contains(result, "[extra]") || !strings.Contains(result, "toc = true") {
+		t.Errorf("expected toc flag in extra, got:\n%s", result)
+	}
+}
+
 func TestConvertToTOML_Aliases(t *testing.T) {
 	f := &JekyllMarkdownFile{
 		Path:        "/fake/2024-03-15-my-post.md",
@@ -275,6 +322,136 @@ func TestConvertToTOML_LastModifiedAt(t *testing.T) {
 	}
 }
 
+func TestConvertToTOML_Drafts(t *testing.T) {
+	modTime := time.Date(2024, 5, 6, 7, 8, 9, 500, time.UTC)
+	tests := []struct {
+		name      string
+		path      string
+		fm        string
+		draftDate time.Time
+		want      string
+	}{
+		{
+			name: "published false",
+			path: "/site/_posts/2024-01-01-post.md",
+			fm:   "title: Post\npublished: false\ndate: 2024-01-01\n",
+			want: "title = \"Post\"\ndate = 2024-01-01T00:00:00Z\ndraft = true\n",
+		},
+		{
+			name: "published true",
+			path: "/site/_posts/2024-01-01-post.md",
+			fm:   "title: Post\npublished: true\n",
+			want: "title = \"Post\"\n",
+		},
+		{
+			name: "draft dated by modification time",
+			path: "/site/_drafts/post.md",
+			fm:   "title: Draft\n",
+			want: "title = \"Draft\"\ndraft = true\ndate = 2024-05-06T07:08:09Z\n",
+		},
+		{
+			name:      "draft dated by draft date",
+			path:      "/site/_drafts/notes/post.md",
+			fm:        "title: Draft\n",
+			draftDate: time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC),
+			want:      "title = \"Draft\"\ndraft = true\ndate = 2025-02-03T00:00:00Z\n",
+		},
+		{
+			name: "unpublished post dated by filename",
+			path: "/site/_posts/2024-01-01-post.md",
+			fm:   "title: Post\npublished: false\n",
+			want: "title = \"Post\"\ndraft = true\ndate = 2024-01-01T00:00:00Z\n",
+		},
+		{
+			name:      "draft date overrides filename",
+			path:      "/site/_posts/2024-01-01-post.md",
+			fm:        "title: Post\npublished: false\n",
+			draftDate: time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC),
+			want:      "title = \"Post\"\ndraft = true\ndate = 2025-02-03T00:00:00Z\n",
+		},
+		{
+			name: "draft keeps its date",
+			path: "/site/_drafts/post.md",
+			fm:   "title: Draft\ndate: 2024-01-01\n",
+			want: "title = \"Draft\"\ndate = 2024-01-01T00:00:00Z\ndraft = true\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &JekyllMarkdownFile{Path: tt.path, FrontMatter: []byte(tt.fm), ModTime: modTime}
+			a := &args.Args{JekyllDir: "/site", Tz: time.UTC, DraftDate: tt.draftDate}
+			if err := f.ConvertToTOML(a); err != nil {
+				t.Fatalf("ConvertToTOML failed: %v", err)
+			}
+			if string(f.FrontMatter) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", f.FrontMatter, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertToTOML_Permalink(t *testing.T) {
+	tests := []struct {
+		name    string
+		fm      string
+		mode    string
+		aliases bool
+		want    string
+	}{
+		{
+			name: "path",
+			fm:   "title: Talk\npermalink: /talks/my-talk/\n",
+			mode: args.PermalinkPath,
+			want: "title = \"Talk\"\npath = \"talks/my-talk\"\n",
+		},
+		{
+			name: "path from html",
+			fm:   "title: Talk\npermalink: /talks/my-talk.html\n",
+			mode: args.PermalinkPath,
+			want: "title = \"Talk\"\npath = \"talks/my-talk\"\n",
+		},
+		{
+			name: "path from index.html",
+			fm:   "title: Talk\npermalink: /talks/index.html\n",
+			mode: args.PermalinkPath,
+			want: "title = \"Talk\"\npath = \"talks\"\n",
+		},
+		{
+			name: "alias",
+			fm:   "title: Talk\npermalink: /talks/my-talk.html\n",
+			mode: args.PermalinkAlias,
+			want: "title = \"Talk\"\naliases = [\"talks/my-talk.html\"]\n",
+		},
+		{
+			name:    "alias appended to filename alias",
+			fm:      "title: Talk\npermalink: /talks/my-talk/\n",
+			mode:    args.PermalinkAlias,
+			aliases: true,
+			want:    "title = \"Talk\"\naliases = [\"2024/03/15/my-talk\", \"talks/my-talk\"]\n",
+		},
+		{
+			name: "placeholders kept in extra",
+			fm:   "title: Talk\npermalink: /:categories/:title/\n",
+			mode: args.PermalinkPath,
+			want: "title = \"Talk\"\n\n[extra]\npermalink = \"/:categories/:title/\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &JekyllMarkdownFile{Path: "/site/_posts/2024-03-15-my-talk.md", FrontMatter: []byte(tt.fm)}
+			a := &args.Args{JekyllDir: "/site", Tz: time.UTC, Permalinks: tt.mode, Aliases: tt.aliases}
+			if err := f.ConvertToTOML(a); err != nil {
+				t.Fatalf("ConvertToTOML failed: %v", err)
+			}
+			if string(f.FrontMatter) != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", f.FrontMatter, tt.want)
+			}
+		})
+	}
+}
+
 func TestConvertToTOML_ExtraRootKeys(t *testing.T) {
 	f := &JekyllMarkdownFile{
 		Path:        "/fake/2024-01-01-test.md",
@@ -301,6 +478,230 @@ func TestConvertToTOML_ExtraRootKeys(t *testing.T) {
 	}
 }
 
+func TestConvertToTOML_KeyOrder(t *testing.T) {
+	f := &JekyllMarkdownFile{
+		Path: "/fake/2024-01-01-test.md",
+		FrontMatter: []byte(`title: "Say \"hi\""
+layout: post
+tags: [go, zola]
+date: 2024-01-01 10:00
+author:
+  name: Ann
+  links: [{site: a.example}]
+description: Test
+ratio: 2.0
+categories: [notes]
+`),
+	}
+
+	a := &args.Args{
+		Taxonomies: []string{"tags", "categories"},
+		Tz:         time.UTC,
+	}
+
+	if err := f.ConvertToTOML(a); err != nil {
+		t.Fatalf("ConvertToTOML failed: %v", err)
+	}
+
+	want := `title = "Say \"hi\""
+date = 2024-01-01T10:00:00Z
+description = "Test"
+
+[taxonomies]
+tags = ["go", "zola"]
+categories = ["notes"]
+
+[extra]
+layout = "post"
+ratio = 2.0
+
+[extra.author]
+name = "Ann"
+links = [{ site = "a.example" }]
+`
+	if string(f.FrontMatter) != want {
+		t.Errorf("\ngot:\n%s\nwant:\n%s", f.FrontMatter, want)
+	}
+
+	var decoded map[string]any
+	if _, err := toml.Decode(string(f.FrontMatter), &decoded); err != nil {
+		t.Errorf("output is not valid TOML: %v", err)
+	}
+}
+
+func TestConvertToTOML_LargeIntegers(t *testing.T) {
+	f := &JekyllMarkdownFile{
+		Path:        "/fake/2024-01-01-test.md",
+		FrontMatter: []byte("big: 12345678901234567890\nmax: 9223372036854775807\n"),
+	}
+
+	if err := f.ConvertToTOML(&args.Args{Tz: time.UTC}); err != nil {
+		t.Fatalf("ConvertToTOML failed: %v", err)
+	}
+
+	want := "[extra]\nbig = \"12345678901234567890\"\nmax = 9223372036854775807\n"
+	if string(f.FrontMatter) != want {
+		t.Errorf("\ngot:\n%s\nwant:\n%s", f.FrontMatter, want)
+	}
+
+	var decoded map[string]any
+	if _, err := toml.Decode(string(f.FrontMatter), &decoded); err != nil {
+		t.Errorf("output is not valid TOML: %v", err)
+	}
+}
+
+func TestConvertToTOML_YAMLFormat(t *testing.T) {
+	f := &JekyllMarkdownFile{
+		Path:        "/fake/2024-01-01-test.md",
+		FrontMatter: []byte("title: Test\nlayout: post\ntags: [go]\ndate: 2024-01-01\nsummary:\n"),
+	}
+
+	a := &args.Args{
+		Taxonomies:        []string{"tags"},
+		Tz:                time.UTC,
+		FrontMatterFormat: args.FormatYAML,
+	}
+
+	if err := f.ConvertToTOML(a); err != nil {
+		t.Fatalf("ConvertToTOML failed: %v", err)
+	}
+
+	want := `title: Test
+date: 2024-01-01T00:00:00Z
+taxonomies:
+  tags:
+    - go
+extra:
+  layout: post
+`
+	if string(f.FrontMatter) != want {
+		t.Errorf("\ngot:\n%s\nwant:\n%s", f.FrontMatter, want)
+	}
+}
+
+func TestConvertToTOML_Rules(t *testing.T) {
+	rules := []args.Rule{
+		{
+			Rename:     map[string]string{"excerpt": "description"},
+			Drop:       []string{"comments"},
+			Transform:  map[string][]string{"keywords": {"lowercase", "split"}},
+			Taxonomies: []string{"keywords"},
+		},
+		{
+			Layout:   "talk",
+			Defaults: map[string]any{"template": "talk.html", "venue": map[string]any{"city": "Oslo"}},
+			Root:     []string{"venue"},
+		},
+		{
+			Path:  "_posts/**",
+			Extra: []string{"description"},
+		},
+		{
+			Path:      "_drafts/*",
+			Transform: map[string][]string{"title": {"uppercase"}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		path string
+		fm   string
+		want string
+	}{
+		{
+			name: "unscoped and layout rules",
+			path: "/site/_pages/talk.md",
+			fm:   "title: Talk\nexcerpt: Short\nlayout: talk\ncomments: true\nkeywords: Go Zola\n",
+			want: `title = "Talk"
+description = "Short"
+template = "talk.html"
+
+[venue]
+city = "Oslo"
+
+[taxonomies]
+keywords = ["go", "zola"]
+
+[extra]
+layout = "talk"
+`,
+		},
+		{
+			name: "path scoped rule",
+			path: "/site/_posts/2024/2024-01-01-post.md",
+			fm:   "title: Post\nexcerpt: Short\n",
+			want: `title = "Post"
+
+[extra]
+description = "Short"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &JekyllMarkdownFile{Path: tt.path, FrontMatter: []byte(tt.fm)}
+			a := &args.Args{JekyllDir: "/site", Tz: time.UTC, Rules: rules}
+			if err := f.ConvertToTOML(a); err != nil {
+				t.Fatalf("ConvertToTOML failed: %v", err)
+			}
+			if string(f.FrontMatter) != tt.want {
+				t.Errorf("\ngot:\n%s\nwant:\n%s", f.FrontMatter, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"rules.toml": "[[rules]]\nlayout = \"post\"\ndrop = [\"layout\"]\n[rules.rename]\nexcerpt = \"description\"\n",
+		"rules.yaml": "rules:\n  - layout: post\n    drop: [layout]\n    rename: {excerpt: description}\n",
+	}
+	for name, data := range files {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		rules, err := LoadRules(p)
+		if err != nil {
+			t.Fatalf("%s: LoadRules failed: %v", name, err)
+		}
+		if len(rules) != 1 || rules[0].Layout != "post" || rules[0].Rename["excerpt"] != "description" || len(rules[0].Drop) != 1 {
+			t.Errorf("%s: unexpected rules: %+v", name, rules)
+		}
+	}
+
+	bad := filepath.Join(dir, "bad.yml")
+	if err := os.WriteFile(bad, []byte("rules:\n  - transform: {tags: [reverse]}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRules(bad); err == nil || !strings.Contains(err.Error(), "unknown transform") {
+		t.Errorf("expected unknown transform error, got %v", err)
+	}
+	if _, err := LoadRules(filepath.Join(dir, "rules.json")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"_posts/*", "_posts/a.md", true},
+		{"_posts/*", "_posts/2024/a.md", false},
+		{"_posts/**", "_posts/2024/a.md", true},
+		{"**/talks/*.md", "_posts/talks/a.md", true},
+		{"_drafts/?.md", "_drafts/ab.md", false},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
 func TestMarkdownFiles(t *testing.T) {
 	// Create a temporary Jekyll directory structure.
 	tmpDir := t.TempDir()
@@ -343,3 +744,98 @@ func TestMarkdownFiles(t *testing.T) {
 		t.Errorf("expected 3 files, got %d: %v", len(files), files)
 	}
 }
+
+func TestBuildSiteIndex(t *testing.T) {
+	jekyllDir := "/site/jekyll"
+	paths := []string{
+		"/site/jekyll/_posts/2024-01-21-amazing-node-red.md",
+		"/site/jekyll/_posts/talks/2023-05-02-my-talk.md",
+		"/site/jekyll/_drafts/no-date.md",
+	}
+
+	index, err := BuildSiteIndex(jekyllDir, paths)
+	if err != nil {
+		t.Fatalf("BuildSiteIndex failed: %v", err)
+	}
+
+	wantPosts := map[string]string{
+		"2024-01-21-amazing-node-red": "posts/amazing-node-red.md",
+		"talks/2023-05-02-my-talk":    "posts/talks/my-talk.md",
+		"2023-05-02-my-talk":          "posts/talks/my-talk.md",
+	}
+	for name, want := range wantPosts {
+		if got := index.Posts[name]; got != want {
+			t.Errorf("Posts[%q] = %q, want %q", name, got, want)
+		}
+	}
+	if _, ok := index.Posts["no-date"]; ok {
+		t.Error("drafts should not be indexed as posts")
+	}
+
+	wantLinks := map[string]string{
+		"_posts/2024-01-21-amazing-node-red.md": "posts/amazing-node-red.md",
+		"_drafts/no-date.md":                    "drafts/no-date.md",
+	}
+	for name, want := range wantLinks {
+		if got := index.Links[name]; got != want {
+			t.Errorf("Links[%q] = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestPermalinkCollisions(t *testing.T) {
+	dir := t.TempDir()
+	pages := map[string]string{
+		"a.md": "---\npermalink: /talks/my-talk/\n---\n",
+		"b.md": "---\npermalink: /talks/my-talk.html\n---\n",
+		"c.md": "---\npermalink: /about/\n---\n",
+		"d.md": "---\ntitle: No permalink\n---\n",
+		"e.md": "no front matter\n",
+	}
+	var paths []string
+	for _, name := range slices.Sorted(maps.Keys(pages)) {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(pages[name]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, p)
+	}
+
+	got := PermalinkCollisions(paths)
+	want := map[string][]string{
+		"talks/my-talk": {filepath.Join(dir, "a.md"), filepath.Join(dir, "b.md")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestWriteShortcodeStubs(t *testing.T) {
+	zolaDir := t.TempDir()
+	shortcodes := filepath.Join(zolaDir, "templates", "shortcodes")
+	if err := os.MkdirAll(shortcodes, 0755); err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(shortcodes, "youtube.html")
+	if err := os.WriteFile(existing, []byte("custom"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &args.Args{ZolaDir: zolaDir, ShortcodeStubs: true}
+	if err := WriteShortcodeStubs(a); err != nil {
+		t.Fatalf("WriteShortcodeStubs failed: %v", err)
+	}
+
+	data, err := os.ReadFile(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "custom" {
+		t.Error("existing shortcode template should not be overwritten")
+	}
+	for _, name := range []string{"gist", "vimeo", "tweet"} {
+		if _, err := os.Stat(filepath.Join(shortcodes, name+".html")); err != nil {
+			t.Errorf("expected stub for %s: %v", name, err)
+		}
+	}
+}
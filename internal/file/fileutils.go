@@ -1,10 +1,19 @@
 package file
 
 import (
+	"errors"
 	"io/fs"
 	"iter"
+	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+
+	"github.com/en9inerd/j2z/internal/args"
+	"github.com/en9inerd/j2z/internal/content"
+	"github.com/en9inerd/j2z/internal/frontmatter"
+	"gopkg.in/yaml.v3"
 )
 
 // MarkdownFiles returns an iterator that lazily yields markdown file paths
@@ -45,23 +54,130 @@ func MarkdownFiles(dir string) iter.Seq2[string, error] {
 	}
 }
 
-func getOutputPaths(file string, jekyllDir *string, zolaDir *string) (string, string, error) {
-	relPath, err := filepath.Rel(*jekyllDir, file)
+// WriteShortcodeStubs writes a stub template under
+// templates/shortcodes/ in the Zola directory for every embed shortcode
+// that has no template yet.
+func WriteShortcodeStubs(a *args.Args) error {
+	dir := filepath.Join(a.ZolaDir, "templates", "shortcodes")
+	for name, stub := range content.ShortcodeStubs(a.EmbedShortcodes) {
+		p := filepath.Join(dir, name+".html")
+		if _, err := os.Stat(p); err == nil {
+			continue
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		if a.DryRun {
+			slog.Info("dry-run: would write shortcode stub", "path", p)
+			continue
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		slog.Debug("writing shortcode stub", "path", p)
+		if err := os.WriteFile(p, []byte(stub), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuildSiteIndex maps every Jekyll file in paths to its Zola content path
+// so that {% post_url %} and {% link %} tags can be resolved before any
+// file is written.
+func BuildSiteIndex(jekyllDir string, paths []string) (*args.SiteIndex, error) {
+	index := &args.SiteIndex{
+		Posts: make(map[string]string),
+		Links: make(map[string]string),
+	}
+
+	postsDir := filepath.Join(jekyllDir, "_posts")
+	for _, p := range paths {
+		relPath, err := filepath.Rel(jekyllDir, p)
+		if err != nil {
+			return nil, err
+		}
+		target, err := contentPath(p, jekyllDir)
+		if err != nil {
+			return nil, err
+		}
+		target = filepath.ToSlash(target)
+		index.Links[filepath.ToSlash(relPath)] = target
+
+		postRel, err := filepath.Rel(postsDir, p)
+		if err != nil || strings.HasPrefix(postRel, "..") {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.ToSlash(postRel), filepath.Ext(postRel))
+		index.Posts[name] = target
+		// post_url also accepts the bare post name for posts in subdirectories.
+		if _, ok := index.Posts[path.Base(name)]; !ok {
+			index.Posts[path.Base(name)] = target
+		}
+	}
+
+	return index, nil
+}
+
+// PermalinkCollisions reads the permalink of every file in paths and
+// returns the normalized permalinks set by more than one file, mapped to
+// those files. Files that cannot be read or parsed are skipped; their
+// errors are reported when they are converted.
+func PermalinkCollisions(paths []string) map[string][]string {
+	owners := make(map[string][]string)
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		fm, err := frontmatter.Extract(data)
+		if err != nil {
+			continue
+		}
+		var page struct {
+			Permalink string `yaml:"permalink"`
+		}
+		if err := yaml.Unmarshal(fm, &page); err != nil {
+			continue
+		}
+		if permalink := normalizePermalink(page.Permalink); permalink != "" && !strings.Contains(permalink, ":") {
+			owners[permalink] = append(owners[permalink], p)
+		}
+	}
+
+	collisions := make(map[string][]string)
+	for permalink, files := range owners {
+		if len(files) > 1 {
+			collisions[permalink] = files
+		}
+	}
+	return collisions
+}
+
+// contentPath returns the path of file relative to the Zola content
+// directory, dropping the leading underscore of the collection directory
+// and the date prefix of the filename.
+func contentPath(file string, jekyllDir string) (string, error) {
+	relPath, err := filepath.Rel(jekyllDir, file)
 	if err != nil {
-		return "", "", err
+		return "", err
 	}
 
 	if len(relPath) > 0 && relPath[0] == '_' {
 		relPath = relPath[1:]
 	}
 
-	name := filepath.Base(relPath)
-	dir := filepath.Dir(relPath)
+	name := stripDatePrefix(filepath.Base(relPath))
+	return filepath.Join(filepath.Dir(relPath), name), nil
+}
 
-	name = stripDatePrefix(name)
-	relPath = filepath.Join(dir, name)
+func getOutputPaths(file string, jekyllDir *string, zolaDir *string) (string, string, error) {
+	relPath, err := contentPath(file, *jekyllDir)
+	if err != nil {
+		return "", "", err
+	}
 
-	return filepath.Join(*zolaDir, "content", relPath), filepath.Join(*zolaDir, "content", dir), nil
+	return filepath.Join(*zolaDir, "content", relPath), filepath.Join(*zolaDir, "content", filepath.Dir(relPath)), nil
 }
 
 // stripDatePrefix removes a leading "YYYY-MM-DD-" prefix from a filename
@@ -0,0 +1,173 @@
+package file
+
+import (
+	"fmt"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// orderedMap is a string-keyed map that remembers the order in which its
+// keys were added, so front matter keeps the author's key order.
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: make(map[string]any)}
+}
+
+// Len returns the number of keys in m.
+func (m *orderedMap) Len() int { return len(m.keys) }
+
+// Keys returns the keys of m in order.
+func (m *orderedMap) Keys() []string { return slices.Clone(m.keys) }
+
+// Get returns the value stored under key.
+func (m *orderedMap) Get(key string) (any, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// Set stores value under key. A new key is added at the end; an existing
+// key keeps its position.
+func (m *orderedMap) Set(key string, value any) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Delete removes key from m.
+func (m *orderedMap) Delete(key string) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+	delete(m.values, key)
+	m.keys = slices.DeleteFunc(m.keys, func(k string) bool { return k == key })
+}
+
+// Rename moves the value of key from to key to, keeping its position. An
+// existing value under to is replaced.
+func (m *orderedMap) Rename(from, to string) {
+	v, ok := m.values[from]
+	if !ok || from == to {
+		return
+	}
+	m.Delete(to)
+	delete(m.values, from)
+	m.keys[slices.Index(m.keys, from)] = to
+	m.values[to] = v
+}
+
+// toMap converts m and the ordered maps nested in it into plain maps.
+func (m *orderedMap) toMap() map[string]any {
+	out := make(map[string]any, len(m.keys))
+	for _, k := range m.keys {
+		out[k] = plainValue(m.values[k])
+	}
+	return out
+}
+
+func plainValue(v any) any {
+	switch v := v.(type) {
+	case *orderedMap:
+		return v.toMap()
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = plainValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+// parseYAMLOrdered parses YAML front matter into an ordered map. Nested
+// mappings become ordered maps and sequences []any; scalars are decoded
+// as by yaml.Unmarshal.
+func parseYAMLOrdered(data []byte) (*orderedMap, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return newOrderedMap(), nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("front matter is not a mapping (line %d)", root.Line)
+	}
+	v, err := decodeNode(root)
+	if err != nil {
+		return nil, err
+	}
+	return v.(*orderedMap), nil
+}
+
+// decodeNode decodes a YAML node, keeping the key order of mappings.
+func decodeNode(n *yaml.Node) (any, error) {
+	switch n.Kind {
+	case yaml.AliasNode:
+		return decodeNode(n.Alias)
+	case yaml.MappingNode:
+		m := newOrderedMap()
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if k.Tag == "!!merge" {
+				if err := mergeInto(m, v); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			value, err := decodeNode(v)
+			if err != nil {
+				return nil, err
+			}
+			m.Set(k.Value, value)
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		list := make([]any, 0, len(n.Content))
+		for _, item := range n.Content {
+			value, err := decodeNode(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	}
+
+	var v any
+	if err := n.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// mergeInto applies a YAML merge key (<<: *anchor) to m. Keys already
+// present in m take precedence.
+func mergeInto(m *orderedMap, n *yaml.Node) error {
+	sources := []*yaml.Node{n}
+	if n.Kind == yaml.SequenceNode {
+		sources = n.Content
+	}
+	for _, src := range sources {
+		v, err := decodeNode(src)
+		if err != nil {
+			return err
+		}
+		sm, ok := v.(*orderedMap)
+		if !ok {
+			return fmt.Errorf("merge value is not a mapping (line %d)", src.Line)
+		}
+		for _, k := range sm.keys {
+			if _, ok := m.Get(k); !ok {
+				m.Set(k, sm.values[k])
+			}
+		}
+	}
+	return nil
+}
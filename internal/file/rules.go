@@ -0,0 +1,193 @@
+package file
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/en9inerd/j2z/internal/args"
+	"github.com/en9inerd/j2z/internal/content"
+	"gopkg.in/yaml.v3"
+)
+
+// Placements a rule can give a front matter key.
+const (
+	placeRoot       = "root"
+	placeExtra      = "extra"
+	placeTaxonomies = "taxonomies"
+)
+
+// ruleTransforms maps the names of the value transforms available to
+// rules to their implementations.
+var ruleTransforms = map[string]func(string) any{
+	"lowercase": func(s string) any { return strings.ToLower(s) },
+	"uppercase": func(s string) any { return strings.ToUpper(s) },
+	"trim":      func(s string) any { return strings.TrimSpace(s) },
+	"slugify":   func(s string) any { return content.Slugify(s) },
+	"split": func(s string) any {
+		var list []any
+		for _, f := range strings.Fields(s) {
+			list = append(list, f)
+		}
+		return list
+	},
+}
+
+// LoadRules reads front matter mapping rules from a TOML or YAML file,
+// picked by its extension. The rules are listed under a "rules" key.
+func LoadRules(path string) ([]args.Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Rules []args.Rule `toml:"rules" yaml:"rules"`
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		err = toml.Unmarshal(data, &file)
+	case ".yml", ".yaml":
+		err = yaml.Unmarshal(data, &file)
+	default:
+		return nil, fmt.Errorf("rules file %s: unsupported extension %q, expected .toml, .yml or .yaml", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("rules file %s: %w", path, err)
+	}
+
+	for i, r := range file.Rules {
+		for key, names := range r.Transform {
+			for _, name := range names {
+				if _, ok := ruleTransforms[name]; !ok {
+					return nil, fmt.Errorf("rules file %s: rule %d: unknown transform %q for key %q", path, i+1, name, key)
+				}
+			}
+		}
+	}
+	return file.Rules, nil
+}
+
+// applyRules applies the rules matching the page to data in order and
+// returns the placement the rules give to keys.
+func (f *JekyllMarkdownFile) applyRules(a *args.Args, data *orderedMap) map[string]string {
+	if len(a.Rules) == 0 {
+		return nil
+	}
+
+	rel := filepath.ToSlash(f.Path)
+	if r, err := filepath.Rel(a.JekyllDir, f.Path); err == nil && a.JekyllDir != "" {
+		rel = filepath.ToSlash(r)
+	}
+	layoutValue, _ := data.Get("layout")
+	layout, _ := layoutValue.(string)
+
+	placement := make(map[string]string)
+	for _, r := range a.Rules {
+		if r.Path != "" && !globMatch(r.Path, rel) {
+			continue
+		}
+		if r.Layout != "" && r.Layout != layout {
+			continue
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(r.Defaults)) {
+			if _, ok := data.Get(key); !ok {
+				data.Set(key, orderedValue(r.Defaults[key]))
+			}
+		}
+		for _, from := range slices.Sorted(maps.Keys(r.Rename)) {
+			data.Rename(from, r.Rename[from])
+		}
+		for _, key := range slices.Sorted(maps.Keys(r.Transform)) {
+			if v, ok := data.Get(key); ok {
+				for _, name := range r.Transform[key] {
+					v = transformValue(v, ruleTransforms[name])
+				}
+				data.Set(key, v)
+			}
+		}
+		for _, key := range r.Drop {
+			data.Delete(key)
+		}
+
+		for _, key := range r.Root {
+			placement[key] = placeRoot
+		}
+		for _, key := range r.Extra {
+			placement[key] = placeExtra
+		}
+		for _, key := range r.Taxonomies {
+			placement[key] = placeTaxonomies
+		}
+	}
+	return placement
+}
+
+// transformValue applies fn to a string value or to each string in a
+// list, flattening lists that fn returns.
+func transformValue(v any, fn func(string) any) any {
+	switch v := v.(type) {
+	case string:
+		return fn(v)
+	case []any:
+		var out []any
+		for _, item := range v {
+			switch t := transformValue(item, fn).(type) {
+			case []any:
+				out = append(out, t...)
+			default:
+				out = append(out, t)
+			}
+		}
+		return out
+	}
+	return v
+}
+
+// orderedValue converts the maps in a value decoded from the rules file
+// into ordered maps with sorted keys.
+func orderedValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := newOrderedMap()
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			m.Set(k, orderedValue(v[k]))
+		}
+		return m
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = orderedValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+// globMatch reports whether name matches the glob pattern, in which "*"
+// and "?" do not match "/" but "**" matches any sequence of characters.
+func globMatch(pattern, name string) bool {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String()).MatchString(name)
+}
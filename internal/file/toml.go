@@ -0,0 +1,156 @@
+package file
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bareKey matches keys that can be written in TOML without quotes.
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// encodeTOML writes m as a TOML document in key order. Plain keys come
+// first, followed by the nested tables in the order of their keys.
+// Keys with nil values are omitted, as TOML has no null.
+func encodeTOML(m *orderedMap) []byte {
+	var sb strings.Builder
+	writeTOMLTable(&sb, nil, m)
+	return []byte(sb.String())
+}
+
+func writeTOMLTable(sb *strings.Builder, path []string, m *orderedMap) {
+	var tables []string
+	for _, k := range m.keys {
+		switch v := m.values[k].(type) {
+		case nil:
+		case *orderedMap:
+			tables = append(tables, k)
+		default:
+			fmt.Fprintf(sb, "%s = %s\n", tomlKey(k), tomlValue(v))
+		}
+	}
+
+	for _, k := range tables {
+		sub := append(path[:len(path):len(path)], k)
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		keys := make([]string, len(sub))
+		for i, p := range sub {
+			keys[i] = tomlKey(p)
+		}
+		fmt.Fprintf(sb, "[%s]\n", strings.Join(keys, "."))
+		writeTOMLTable(sb, sub, m.values[k].(*orderedMap))
+	}
+}
+
+// tomlKey quotes k unless it is a valid bare key.
+func tomlKey(k string) string {
+	if bareKey.MatchString(k) {
+		return k
+	}
+	return tomlString(k)
+}
+
+// tomlValue formats an inline TOML value. Maps inside arrays are written
+// as inline tables. Integers beyond the int64 range TOML allows are
+// written as strings.
+func tomlValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return tomlString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		if v > math.MaxInt64 {
+			return tomlString(strconv.FormatUint(v, 10))
+		}
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return tomlFloat(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []string:
+		items := make([]string, len(v))
+		for i, s := range v {
+			items[i] = tomlString(s)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if item != nil {
+				items = append(items, tomlValue(item))
+			}
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case *orderedMap:
+		items := make([]string, 0, v.Len())
+		for _, k := range v.keys {
+			if v.values[k] != nil {
+				items = append(items, tomlKey(k)+" = "+tomlValue(v.values[k]))
+			}
+		}
+		if len(items) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(items, ", ") + " }"
+	}
+	return tomlString(fmt.Sprint(v))
+}
+
+// tomlFloat formats f so that TOML reads it back as a float.
+func tomlFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
+
+// tomlString formats s as a TOML basic string.
+func tomlString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
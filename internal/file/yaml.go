@@ -0,0 +1,64 @@
+package file
+
+import (
+	"bytes"
+
+	"gopkg.in/yaml.v3"
+)
+
+// encodeYAML writes m as a YAML document in key order. Keys with nil
+// values are omitted, as in the TOML output.
+func encodeYAML(m *orderedMap) ([]byte, error) {
+	node, err := yamlNode(m)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlNode builds the YAML node for a front matter value.
+func yamlNode(v any) (*yaml.Node, error) {
+	switch v := v.(type) {
+	case *orderedMap:
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		for _, k := range v.keys {
+			if v.values[k] == nil {
+				continue
+			}
+			value, err := yamlNode(v.values[k])
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: k}, value)
+		}
+		return n, nil
+	case []any:
+		n := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range v {
+			if item == nil {
+				continue
+			}
+			value, err := yamlNode(item)
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, value)
+		}
+		return n, nil
+	}
+
+	n := &yaml.Node{}
+	if err := n.Encode(v); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
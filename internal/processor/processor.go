@@ -15,6 +15,9 @@ func ProcessMarkdownFile(
 	if err := file.ProcessFrontMatter(); err != nil {
 		return err
 	}
+	if err := file.ConvertContent(args); err != nil {
+		return err
+	}
 	if err := file.ConvertToTOML(args); err != nil {
 		return err
 	}